@@ -0,0 +1,77 @@
+// Command example parses Djinni IDL files and dumps their AST as JSON.
+//
+// Usage:
+//
+//	example [-indent] [-o file] file.djinni...
+//
+// A single file is written as one JSON object, several files as an array
+// of objects in the order they were given.
+//
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/ast"
+	"github.com/SafetyCulture/djinni-parser/pkg/parser"
+)
+
+func main() {
+	indent := flag.Bool("indent", false, "indent the JSON output")
+	output := flag.String("o", "", "write the output to `file` instead of stdout")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-indent] [-o file] file.djinni...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Args(), *output, *indent); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(filenames []string, output string, indent bool) error {
+	files := make([]*ast.IDLFile, 0, len(filenames))
+	for _, filename := range filenames {
+		f, err := parser.ParseFile(filename, nil)
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+	}
+
+	var v interface{} = files
+	if len(files) == 1 {
+		v = files[0]
+	}
+
+	if output == "" {
+		return encode(os.Stdout, v, indent)
+	}
+	out, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := encode(out, v, indent); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func encode(w io.Writer, v interface{}, indent bool) error {
+	enc := json.NewEncoder(w)
+	if indent {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(v)
+}
@@ -4,6 +4,8 @@ package ast
 
 import (
 	"strings"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/token"
 )
 
 // ----------------------------------------------------------------------------
@@ -11,7 +13,8 @@ import (
 
 // Comment node represents a single #-style comment.
 type Comment struct {
-	Text string // comment text excluding '\n'
+	Pos  token.Pos // position of the '#'
+	Text string    // comment text excluding '\n'
 }
 
 // CommentGroup represents a sequence of comments
@@ -65,11 +68,41 @@ func (g *CommentGroup) Text() string {
 	return strings.Join(lines, "\n")
 }
 
+// Summary returns the first line of the comment text, e.g. for use
+// as a tooltip.
+func (g *CommentGroup) Summary() string {
+	text := g.Text()
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		return text[:i]
+	}
+	return text
+}
+
+// Description returns the comment text following the summary line,
+// with any empty lines directly after the summary removed.
+func (g *CommentGroup) Description() string {
+	text := g.Text()
+	i := strings.IndexByte(text, '\n')
+	if i < 0 {
+		return ""
+	}
+	return strings.TrimLeft(text[i+1:], "\n")
+}
+
 // ----------------------------------------------------------------------------
 // Interfaces
 
+// All node types implement the Node interface. As nodes record their
+// positions in Pos and End fields, the interface only marks the types
+// that are part of the syntax tree and reports their Kind.
+type Node interface {
+	Kind() Kind
+	node()
+}
+
 // All outer expression nodes implement the TypeDef interface.
 type TypeDef interface {
+	Node
 	typeDefNode()
 }
 
@@ -79,15 +112,65 @@ type TypeDef interface {
 type (
 	// Ident node represents an identifier.
 	Ident struct {
+		Pos  token.Pos // position of the identifier
+		End  token.Pos // position immediately after the identifier
 		Name string
 	}
 
 	// Const node represents a constant.
+	// The Value is an int64, float64, string, bool, NullValue, Ref,
+	// RecordLiteral, MapValue or, for a list or set, a []interface{} of
+	// such values. Escape sequences in strings have been interpreted.
 	Const struct {
+		Pos   token.Pos     // position of the const keyword
+		End   token.Pos     // position immediately after the ';'
 		Doc   *CommentGroup // associated documentation; or nil
 		Ident Ident         // name of the constant
 		Type  TypeExpr      // the type of the constant
 		Value interface{}   // the value of the constant
+		Raw   string        // source text of the value, e.g. 0xFF for the Value 255
+	}
+
+	// NullValue represents the absent value of an optional constant.
+	NullValue struct{}
+
+	// Ref represents a value that refers to another constant, as in
+	// `base`, or to an option qualified by its enum, as in `color.red`.
+	// See parser.Resolve for checking that the referenced value exists.
+	Ref struct {
+		Scope *Ident // the enum of a qualified reference; or nil
+		Ident Ident  // name of the referenced constant or option
+	}
+
+	// RecordLiteral represents the value of a constant of a record type.
+	RecordLiteral struct {
+		Fields []FieldValue // field values, in declaration order; or nil
+	}
+
+	// FieldValue represents a single field of a RecordLiteral.
+	FieldValue struct {
+		Ident Ident       // name of the field
+		Value interface{} // the value of the field, as for Const
+	}
+
+	// MapValue represents the value of a constant of a map type.
+	MapValue struct {
+		Entries []MapEntry // entries, in declaration order; or nil
+	}
+
+	// MapEntry represents a single key-value pair of a MapValue.
+	MapEntry struct {
+		Key   interface{} // the key of the entry, as for Const
+		Value interface{} // the value of the entry, as for Const
+	}
+
+	// Annotation represents a directive such as @json that is attached
+	// to a type declaration.
+	Annotation struct {
+		Pos   token.Pos // position of the '@'
+		End   token.Pos // position immediately after the annotation
+		Name  string    // name of the annotation, excluding the leading '@'
+		Value string    // literal value following an '=', e.g. 3 for @field=3; or empty
 	}
 
 	// Ext represents the extension flags that are supported
@@ -95,79 +178,275 @@ type (
 		CPP  bool
 		ObjC bool
 		Java bool
+		Wasm bool
+		Node bool
+	}
+
+	// Deriving represents the traits derived by a type
+	Deriving struct {
+		Eq         bool
+		Ord        bool
+		Parcelable bool
 	}
 
 	// EnumOption represents a single option of an enumeration
 	EnumOption struct {
-		Doc   *CommentGroup // associated documentation; or nil
-		Ident Ident         // name of the option
+		Pos      token.Pos     // position of the option's identifier
+		End      token.Pos     // position immediately after the ';' or ',', if any
+		Doc      *CommentGroup // associated documentation; or nil
+		Ident    Ident         // name of the option
+		Value    *int          // explicit value, as in `red = 0;`; or nil
+		RawValue string        // source text of the Value, e.g. 0x01 for 1; or empty
+		IsAll    bool          // flags option with all flags set, as in `everything = all;`
+		IsNone   bool          // flags option with no flags set, as in `nothing = none;`
+		Comment  *CommentGroup // line comment following the ';'; or nil
 	}
 
+	// TypeExpr represents a type, including any generic arguments.
 	TypeExpr struct {
-		Ident Ident      // expression type name, eg. i32, i64, string, map, set
-		Args  []TypeExpr // arguments to any generic types like map, set and list; or nil
+		Pos       token.Pos  // position of the type name
+		End       token.Pos  // position immediately after the type
+		Ident     Ident      // expression type name, eg. i32, i64, string, map, set
+		Args      []TypeExpr // arguments to any generic types like map, set and list; or nil
+		Interface bool       // the type names an interface; set by parser.Resolve
 	}
 
 	Field struct {
-		Doc   *CommentGroup // associated documentation; or nil
-		Ident Ident         // name of the field
-		Type  TypeExpr      // the type of the field
+		Pos         token.Pos     // position of the field's identifier
+		End         token.Pos     // position immediately after the field
+		Doc         *CommentGroup // associated documentation; or nil
+		Annotations []Annotation  // annotations preceding the field; or nil
+		Ident       Ident         // name of the field
+		Type        TypeExpr      // the type of the field
+		Number      int           // field number, see parser.WithFieldNumbers; or 0
+		Default     interface{}   // default value, as for Const, as in `score: i32 = 0;`; or nil
+		DefaultRaw  string        // source text of the default value; or empty
+		Comment     *CommentGroup // line comment following the ';'; or nil
 	}
 
 	Method struct {
+		Pos    token.Pos     // position of the first token of the method
+		End    token.Pos     // position immediately after the ';'
 		Doc    *CommentGroup // associated documentation; or nil
 		Ident  Ident         // name of the method
-		Params []Field
-		Return TypeExpr
-		Static bool // static method if true
-		Const  bool // has been defined as a constant
+		Params []Field       // parameters of the method; or nil
+		Return *TypeExpr     // return type of the method; or nil
+		Static bool          // static method if true
+		Const  bool          // has been defined as a constant
+		Ext    Ext           // trailing extension hints, written after the return type
 	}
 )
 
 type (
 	// Enum node represents an enumeration of options.
 	Enum struct {
-		Options []EnumOption // options for the enumernation; or nil
-		Flags   bool         // true if the enum is defineds as flags
+		Pos      token.Pos    // position of the enum or flags keyword
+		End      token.Pos    // position immediately after the definition
+		Options  []EnumOption // options for the enumernation; or nil
+		Flags    bool         // true if the enum is defineds as flags
+		Deriving Deriving     // the derived traits, see parser.WithEnumDeriving
 	}
 
 	// Record reperesents a pure-data value object.
 	Record struct {
-		Ext    Ext // The extra extensions
-		Fields []Field
-		Consts []Const
+		Pos      token.Pos // position of the record keyword
+		End      token.Pos // position immediately after the definition
+		Ext      Ext       // The extra extensions
+		Fields   []Field
+		Consts   []Const
+		Deriving Deriving // The derived traits
 	}
 
 	// Interface defines an object with defined methods to call.
 	Interface struct {
-		Ext     Ext // The extensions supported
+		Pos     token.Pos // position of the interface keyword
+		End     token.Pos // position immediately after the definition
+		Ext     Ext       // The extensions supported
 		Methods []Method
 		Consts  []Const
 	}
 
 	// BadDef reperesents a bad type definition
-	BadDef struct{}
+	BadDef struct {
+		Pos token.Pos // position of the first bad token
+		End token.Pos // position immediately after the skipped definition
+	}
 )
 
+func (*Comment) node()      {}
+func (*CommentGroup) node() {}
+func (*Ident) node()        {}
+func (*Const) node()        {}
+func (*Annotation) node()   {}
+func (*EnumOption) node()   {}
+func (*TypeExpr) node()     {}
+func (*Field) node()        {}
+func (*Method) node()       {}
+func (*Enum) node()         {}
+func (*Record) node()       {}
+func (*Interface) node()    {}
+func (*BadDef) node()       {}
+func (*TypeDecl) node()     {}
+func (*IDLFile) node()      {}
+
 func (*Enum) typeDefNode()      {}
 func (*Record) typeDefNode()    {}
 func (*Interface) typeDefNode() {}
 func (*BadDef) typeDefNode()    {}
 
+// String returns the type as it is written in Djinni IDL,
+// e.g. map<string, list<i32>>.
+func (t TypeExpr) String() string {
+	if len(t.Args) == 0 {
+		return t.Ident.Name
+	}
+	args := make([]string, len(t.Args))
+	for i, a := range t.Args {
+		args[i] = a.String()
+	}
+	return t.Ident.Name + "<" + strings.Join(args, ", ") + ">"
+}
+
+// String returns the reference as it is written in Djinni IDL,
+// e.g. color.red.
+func (r Ref) String() string {
+	if r.Scope == nil {
+		return r.Ident.Name
+	}
+	return r.Scope.Name + "." + r.Ident.Name
+}
+
+// Compatible reports whether a value of type a can be used where a value
+// of type b is expected. This is the case if the types are identical, or
+// if b is optional<T> and a is compatible with T.
+func Compatible(a, b TypeExpr) bool {
+	if identical(a, b) {
+		return true
+	}
+	return b.Ident.Name == "optional" && len(b.Args) == 1 && Compatible(a, b.Args[0])
+}
+
+// FlattenOptional returns t with nested optionals collapsed into a single
+// optional at every level, e.g. list<optional<optional<i32>>> becomes
+// list<optional<i32>>. A type without nested optionals is returned
+// unchanged.
+func FlattenOptional(t TypeExpr) TypeExpr {
+	for t.Ident.Name == "optional" && len(t.Args) == 1 && t.Args[0].Ident.Name == "optional" {
+		inner := t.Args[0]
+		inner.Pos, inner.End = t.Pos, t.End
+		t = inner
+	}
+	if len(t.Args) == 0 {
+		return t
+	}
+	args := make([]TypeExpr, len(t.Args))
+	for i, arg := range t.Args {
+		args[i] = FlattenOptional(arg)
+	}
+	t.Args = args
+	return t
+}
+
+// identical reports whether a and b denote the same type.
+func identical(a, b TypeExpr) bool {
+	if a.Ident.Name != b.Ident.Name || len(a.Args) != len(b.Args) {
+		return false
+	}
+	for i := range a.Args {
+		if !identical(a.Args[i], b.Args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 // ----------------------------------------------------------------------------
 // Declarations
 
 // A TypeDecl node represents an enum, flags, record or interface decleration
 type TypeDecl struct {
-	Doc   *CommentGroup // associated documentation; or nil
-	Ident Ident         // name of the identifier
-	Body  TypeDef       // decleration type
+	Pos         token.Pos     // position of the identifier
+	End         token.Pos     // position immediately after the declaration
+	Doc         *CommentGroup // associated documentation; or nil
+	Ident       Ident         // name of the identifier
+	Annotations []Annotation  // directives preceding the type keyword; or nil
+	Body        TypeDef       // decleration type
+	RawSource   []byte        // source text from Pos to End, see parser.WithRawSource; or nil
+	Filename    string        // name of the file the declaration was parsed from; or ""
 }
 
 // ----------------------------------------------------------------------------
 // Files
 
 type IDLFile struct {
-	Imports   []string   // imports in this file
-	TypeDecls []TypeDecl // top-level declarations; or nil
+	Imports   []string        // imports in this file
+	Externs   []string        // paths of @extern files defining external types; or nil
+	TypeDecls []TypeDecl      // top-level declarations; or nil
+	Comments  []*CommentGroup // list of all comments in the source file
+	Namespace []string        // segments of the @namespace declaration; or nil
+	Imported  []*IDLFile      // parsed imports, see parser.ParseFileWithImports; or nil
+}
+
+// Records returns the declarations in f whose body is a record.
+func (f *IDLFile) Records() []TypeDecl {
+	return f.filter(func(def TypeDef) bool {
+		_, ok := def.(*Record)
+		return ok
+	})
+}
+
+// Enums returns the declarations in f whose body is an enum.
+// Flags are not included, see Flags.
+func (f *IDLFile) Enums() []TypeDecl {
+	return f.filter(func(def TypeDef) bool {
+		e, ok := def.(*Enum)
+		return ok && !e.Flags
+	})
+}
+
+// Flags returns the declarations in f whose body is a flags enum.
+func (f *IDLFile) Flags() []TypeDecl {
+	return f.filter(func(def TypeDef) bool {
+		e, ok := def.(*Enum)
+		return ok && e.Flags
+	})
+}
+
+// Interfaces returns the declarations in f whose body is an interface.
+func (f *IDLFile) Interfaces() []TypeDecl {
+	return f.filter(func(def TypeDef) bool {
+		_, ok := def.(*Interface)
+		return ok
+	})
+}
+
+func (f *IDLFile) filter(keep func(TypeDef) bool) []TypeDecl {
+	var decls []TypeDecl
+	for _, d := range f.TypeDecls {
+		if keep(d.Body) {
+			decls = append(decls, d)
+		}
+	}
+	return decls
+}
+
+// AllTypeDecls returns the declarations of f followed by those of all
+// transitively imported files. A file imported more than once contributes
+// its declarations only once.
+func (f *IDLFile) AllTypeDecls() []TypeDecl {
+	var decls []TypeDecl
+	seen := make(map[*IDLFile]bool)
+	var walk func(*IDLFile)
+	walk = func(f *IDLFile) {
+		if seen[f] {
+			return
+		}
+		seen[f] = true
+		decls = append(decls, f.TypeDecls...)
+		for _, imp := range f.Imported {
+			walk(imp)
+		}
+	}
+	walk(f)
+	return decls
 }
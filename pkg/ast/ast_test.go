@@ -0,0 +1,137 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/ast"
+)
+
+func TestIDLFileFilters(t *testing.T) {
+	t.Parallel()
+
+	f := &ast.IDLFile{
+		TypeDecls: []ast.TypeDecl{
+			{Ident: ast.Ident{Name: "my_record"}, Body: &ast.Record{}},
+			{Ident: ast.Ident{Name: "my_enum"}, Body: &ast.Enum{}},
+			{Ident: ast.Ident{Name: "my_flags"}, Body: &ast.Enum{Flags: true}},
+			{Ident: ast.Ident{Name: "my_interface"}, Body: &ast.Interface{}},
+			{Ident: ast.Ident{Name: "other_record"}, Body: &ast.Record{}},
+			{Ident: ast.Ident{Name: "bad"}, Body: &ast.BadDef{}},
+		},
+	}
+
+	tests := [...]struct {
+		name string
+		got  []ast.TypeDecl
+		want []string
+	}{
+		{"Records", f.Records(), []string{"my_record", "other_record"}},
+		{"Enums", f.Enums(), []string{"my_enum"}},
+		{"Flags", f.Flags(), []string{"my_flags"}},
+		{"Interfaces", f.Interfaces(), []string{"my_interface"}},
+	}
+
+	for _, tt := range tests {
+		if len(tt.got) != len(tt.want) {
+			t.Errorf("%s: incorrect number of decls; expected %d, got %d", tt.name, len(tt.want), len(tt.got))
+			continue
+		}
+		for i, d := range tt.got {
+			if d.Ident.Name != tt.want[i] {
+				t.Errorf("%s: incorrect decl %d: expected %q, got %q", tt.name, i, tt.want[i], d.Ident.Name)
+			}
+		}
+	}
+}
+
+func TestCommentGroupSummary(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name        string
+		comments    []string
+		summary     string
+		description string
+	}{
+		{"Nil", nil, "", ""},
+		{"SingleLine", []string{"# the id"}, "the id", ""},
+		{"MultiLine", []string{"# the id", "#", "# unique for each record", "# and never reused"}, "the id", "unique for each record\nand never reused"},
+	}
+
+	for _, tt := range tests {
+		var g *ast.CommentGroup
+		if tt.comments != nil {
+			g = &ast.CommentGroup{}
+			for _, c := range tt.comments {
+				g.List = append(g.List, &ast.Comment{Text: c})
+			}
+		}
+
+		if got := g.Summary(); got != tt.summary {
+			t.Errorf("%s: incorrect summary: expected %q, got %q", tt.name, tt.summary, got)
+		}
+		if got := g.Description(); got != tt.description {
+			t.Errorf("%s: incorrect description: expected %q, got %q", tt.name, tt.description, got)
+		}
+	}
+}
+
+func TestCompatible(t *testing.T) {
+	t.Parallel()
+
+	typ := func(name string, args ...ast.TypeExpr) ast.TypeExpr {
+		return ast.TypeExpr{Ident: ast.Ident{Name: name}, Args: args}
+	}
+	i32 := typ("i32")
+	strings := typ("list", typ("string"))
+
+	tests := [...]struct {
+		name string
+		a, b ast.TypeExpr
+		want bool
+	}{
+		{"identical", i32, typ("i32"), true},
+		{"identical generic", strings, typ("list", typ("string")), true},
+		{"optional widening", i32, typ("optional", i32), true},
+		{"optional generic widening", strings, typ("optional", strings), true},
+		{"optional narrowing", typ("optional", i32), i32, false},
+		{"different types", i32, typ("i64"), false},
+		{"different args", strings, typ("list", i32), false},
+		{"different containers", strings, typ("set", typ("string")), false},
+	}
+
+	for _, tt := range tests {
+		if got := ast.Compatible(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: Compatible(%s, %s) = %t, expected %t", tt.name, tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFlattenOptional(t *testing.T) {
+	t.Parallel()
+
+	typ := func(name string, args ...ast.TypeExpr) ast.TypeExpr {
+		return ast.TypeExpr{Ident: ast.Ident{Name: name}, Args: args}
+	}
+	optional := func(t ast.TypeExpr) ast.TypeExpr { return typ("optional", t) }
+	str := typ("string")
+
+	tests := [...]struct {
+		name string
+		typ  ast.TypeExpr
+		want string
+	}{
+		{"plain", str, "string"},
+		{"optional", optional(str), "optional<string>"},
+		{"double", optional(optional(str)), "optional<string>"},
+		{"triple", optional(optional(optional(str))), "optional<string>"},
+		{"nested in args", typ("map", str, typ("list", optional(optional(str)))), "map<string, list<optional<string>>>"},
+		{"separated", optional(typ("list", optional(str))), "optional<list<optional<string>>>"},
+	}
+
+	for _, tt := range tests {
+		if got := ast.FlattenOptional(tt.typ).String(); got != tt.want {
+			t.Errorf("%s: incorrect type: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package ast
+
+// Clone returns a deep copy of f: changing the copy, including its
+// declarations, constant values and comments, leaves f unchanged. The
+// comment groups are shared between the Doc fields and the Comments of
+// the copy as they are in f, and so are the files in Imported.
+func Clone(f *IDLFile) *IDLFile {
+	c := cloner{
+		groups: make(map[*CommentGroup]*CommentGroup),
+		files:  make(map[*IDLFile]*IDLFile),
+	}
+	return c.file(f)
+}
+
+type cloner struct {
+	groups map[*CommentGroup]*CommentGroup // copies of the comment groups seen so far
+	files  map[*IDLFile]*IDLFile           // copies of the files seen so far
+}
+
+func (c *cloner) file(f *IDLFile) *IDLFile {
+	if f == nil {
+		return nil
+	}
+	if cf, ok := c.files[f]; ok {
+		return cf
+	}
+	cf := &IDLFile{
+		Imports:   cloneStrings(f.Imports),
+		Externs:   cloneStrings(f.Externs),
+		Namespace: cloneStrings(f.Namespace),
+	}
+	c.files[f] = cf
+	if f.Comments != nil {
+		cf.Comments = make([]*CommentGroup, len(f.Comments))
+		for i, g := range f.Comments {
+			cf.Comments[i] = c.group(g)
+		}
+	}
+	if f.TypeDecls != nil {
+		cf.TypeDecls = make([]TypeDecl, len(f.TypeDecls))
+		for i, d := range f.TypeDecls {
+			cf.TypeDecls[i] = c.typeDecl(d)
+		}
+	}
+	if f.Imported != nil {
+		cf.Imported = make([]*IDLFile, len(f.Imported))
+		for i, imp := range f.Imported {
+			cf.Imported[i] = c.file(imp)
+		}
+	}
+	return cf
+}
+
+func (c *cloner) group(g *CommentGroup) *CommentGroup {
+	if g == nil {
+		return nil
+	}
+	if cg, ok := c.groups[g]; ok {
+		return cg
+	}
+	cg := &CommentGroup{}
+	if g.List != nil {
+		cg.List = make([]*Comment, len(g.List))
+		for i, comment := range g.List {
+			cc := *comment
+			cg.List[i] = &cc
+		}
+	}
+	c.groups[g] = cg
+	return cg
+}
+
+func (c *cloner) typeDecl(d TypeDecl) TypeDecl {
+	d.Doc = c.group(d.Doc)
+	d.Annotations = cloneAnnotations(d.Annotations)
+	if d.RawSource != nil {
+		d.RawSource = append([]byte{}, d.RawSource...)
+	}
+	switch def := d.Body.(type) {
+	case *Enum:
+		e := *def
+		if def.Options != nil {
+			e.Options = make([]EnumOption, len(def.Options))
+			for i, o := range def.Options {
+				e.Options[i] = c.enumOption(o)
+			}
+		}
+		d.Body = &e
+	case *Record:
+		r := *def
+		if def.Fields != nil {
+			r.Fields = make([]Field, len(def.Fields))
+			for i, f := range def.Fields {
+				r.Fields[i] = c.field(f)
+			}
+		}
+		r.Consts = c.consts(def.Consts)
+		d.Body = &r
+	case *Interface:
+		iface := *def
+		if def.Methods != nil {
+			iface.Methods = make([]Method, len(def.Methods))
+			for i, m := range def.Methods {
+				iface.Methods[i] = c.method(m)
+			}
+		}
+		iface.Consts = c.consts(def.Consts)
+		d.Body = &iface
+	case *BadDef:
+		bad := *def
+		d.Body = &bad
+	}
+	return d
+}
+
+func (c *cloner) enumOption(o EnumOption) EnumOption {
+	o.Doc = c.group(o.Doc)
+	o.Comment = c.group(o.Comment)
+	if o.Value != nil {
+		v := *o.Value
+		o.Value = &v
+	}
+	return o
+}
+
+func (c *cloner) field(f Field) Field {
+	f.Doc = c.group(f.Doc)
+	f.Comment = c.group(f.Comment)
+	f.Annotations = cloneAnnotations(f.Annotations)
+	f.Type = cloneType(f.Type)
+	f.Default = cloneValue(f.Default)
+	return f
+}
+
+func (c *cloner) consts(list []Const) []Const {
+	if list == nil {
+		return nil
+	}
+	cl := make([]Const, len(list))
+	for i, k := range list {
+		k.Doc = c.group(k.Doc)
+		k.Type = cloneType(k.Type)
+		k.Value = cloneValue(k.Value)
+		cl[i] = k
+	}
+	return cl
+}
+
+func (c *cloner) method(m Method) Method {
+	m.Doc = c.group(m.Doc)
+	if m.Params != nil {
+		params := make([]Field, len(m.Params))
+		for i, p := range m.Params {
+			params[i] = c.field(p)
+		}
+		m.Params = params
+	}
+	if m.Return != nil {
+		ret := cloneType(*m.Return)
+		m.Return = &ret
+	}
+	return m
+}
+
+func cloneType(t TypeExpr) TypeExpr {
+	if t.Args != nil {
+		args := make([]TypeExpr, len(t.Args))
+		for i, arg := range t.Args {
+			args[i] = cloneType(arg)
+		}
+		t.Args = args
+	}
+	return t
+}
+
+// cloneValue returns a deep copy of the constant value v, see Const.
+func cloneValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case Ref:
+		if v.Scope != nil {
+			scope := *v.Scope
+			v.Scope = &scope
+		}
+		return v
+	case RecordLiteral:
+		if v.Fields != nil {
+			fields := make([]FieldValue, len(v.Fields))
+			for i, f := range v.Fields {
+				fields[i] = FieldValue{Ident: f.Ident, Value: cloneValue(f.Value)}
+			}
+			v.Fields = fields
+		}
+		return v
+	case MapValue:
+		if v.Entries != nil {
+			entries := make([]MapEntry, len(v.Entries))
+			for i, e := range v.Entries {
+				entries[i] = MapEntry{Key: cloneValue(e.Key), Value: cloneValue(e.Value)}
+			}
+			v.Entries = entries
+		}
+		return v
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		list := make([]interface{}, len(v))
+		for i, e := range v {
+			list[i] = cloneValue(e)
+		}
+		return list
+	}
+	// the remaining values are immutable
+	return v
+}
+
+func cloneAnnotations(list []Annotation) []Annotation {
+	if list == nil {
+		return nil
+	}
+	return append(make([]Annotation, 0, len(list)), list...)
+}
+
+func cloneStrings(list []string) []string {
+	if list == nil {
+		return nil
+	}
+	return append(make([]string, 0, len(list)), list...)
+}
@@ -0,0 +1,65 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/ast"
+	"github.com/SafetyCulture/djinni-parser/pkg/parser"
+)
+
+func TestClone(t *testing.T) {
+	t.Parallel()
+	src := `
+		@import "common.djinni"
+
+		# a record
+		my_record = @json record +c {
+			# the id
+			id: i32;
+			names: map<string, list<i32>> = { "a": [1, 2] };
+			const origin: point = { x = 1, y = { z = "z" } };
+			const first: my_enum = my_enum.first;
+			const primes: list<i32> = [2, 3, 5];
+		} deriving (eq)
+		my_enum = enum { first = 1; second; # trailing
+		}
+		my_interface = interface +o { get(key: string): optional<my_record>; const version: i32 = 1; }
+	`
+	parse := func() *ast.IDLFile {
+		f, err := parser.ParseFile("", src, parser.WithComments(), parser.WithRawSource())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	f, want := parse(), parse()
+
+	c := ast.Clone(f)
+	if diff := cmp.Diff(f, c); diff != "" {
+		t.Fatalf("incorrect clone:\n%s", diff)
+	}
+	if c.TypeDecls[0].Doc != c.Comments[0] {
+		t.Error("doc comment of the clone isn't shared with its comments")
+	}
+
+	c.Imports[0] = "other.djinni"
+	c.Comments[0].List[0].Text = "# changed"
+	decl := &c.TypeDecls[0]
+	decl.RawSource[0] = 'X'
+	decl.Annotations[0].Name = "xml"
+	r := decl.Body.(*ast.Record)
+	r.Fields[1].Type.Args[1].Args[0].Ident.Name = "i64"
+	r.Fields[1].Default.(ast.MapValue).Entries[0].Value.([]interface{})[0] = int64(9)
+	r.Consts[0].Value.(ast.RecordLiteral).Fields[1].Value.(ast.RecordLiteral).Fields[0].Value = "changed"
+	r.Consts[1].Value.(ast.Ref).Scope.Name = "other_enum"
+	r.Consts[2].Value.([]interface{})[0] = int64(7)
+	*c.TypeDecls[1].Body.(*ast.Enum).Options[0].Value = 2
+	c.TypeDecls[1].Body.(*ast.Enum).Options[1].Comment.List[0].Text = "# changed"
+	c.TypeDecls[2].Body.(*ast.Interface).Methods[0].Return.Args[0].Ident.Name = "other"
+
+	if diff := cmp.Diff(want, f); diff != "" {
+		t.Errorf("changing the clone changed the original:\n%s", diff)
+	}
+}
@@ -0,0 +1,261 @@
+package ast
+
+// Equal reports whether the nodes a and b are structurally identical.
+// Positions and the comments attached to nodes are ignored, and so are the
+// raw source and filename of a declaration and the source text of values,
+// so two declarations are equal if they only differ in layout, spelling or
+// documentation. Nodes of different types are never equal. Comments
+// themselves are compared by their text.
+func Equal(a, b Node) bool {
+	switch a := a.(type) {
+	case nil:
+		return b == nil
+	case *Comment:
+		b, ok := b.(*Comment)
+		return ok && (a == b || a != nil && b != nil && a.Text == b.Text)
+	case *CommentGroup:
+		b, ok := b.(*CommentGroup)
+		return ok && (a == b || a != nil && b != nil && equalComments(a.List, b.List))
+	case *Ident:
+		b, ok := b.(*Ident)
+		return ok && (a == b || a != nil && b != nil && a.Name == b.Name)
+	case *Const:
+		b, ok := b.(*Const)
+		return ok && (a == b || a != nil && b != nil && equalConst(*a, *b))
+	case *Annotation:
+		b, ok := b.(*Annotation)
+		return ok && (a == b || a != nil && b != nil && equalAnnotation(*a, *b))
+	case *EnumOption:
+		b, ok := b.(*EnumOption)
+		return ok && (a == b || a != nil && b != nil && equalEnumOption(*a, *b))
+	case *TypeExpr:
+		b, ok := b.(*TypeExpr)
+		return ok && (a == b || a != nil && b != nil && identical(*a, *b))
+	case *Field:
+		b, ok := b.(*Field)
+		return ok && (a == b || a != nil && b != nil && equalField(*a, *b))
+	case *Method:
+		b, ok := b.(*Method)
+		return ok && (a == b || a != nil && b != nil && equalMethod(*a, *b))
+	case *Enum:
+		b, ok := b.(*Enum)
+		return ok && (a == b || a != nil && b != nil && equalEnum(*a, *b))
+	case *Record:
+		b, ok := b.(*Record)
+		return ok && (a == b || a != nil && b != nil && equalRecord(*a, *b))
+	case *Interface:
+		b, ok := b.(*Interface)
+		return ok && (a == b || a != nil && b != nil && equalInterface(*a, *b))
+	case *BadDef:
+		b, ok := b.(*BadDef)
+		return ok && (a == nil) == (b == nil)
+	case *TypeDecl:
+		b, ok := b.(*TypeDecl)
+		return ok && (a == b || a != nil && b != nil && equalTypeDecl(*a, *b))
+	case *IDLFile:
+		b, ok := b.(*IDLFile)
+		return ok && (a == b || a != nil && b != nil && equalFile(a, b))
+	}
+	return false
+}
+
+func equalComments(a, b []*Comment) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Text != b[i].Text {
+			return false
+		}
+	}
+	return true
+}
+
+func equalConst(a, b Const) bool {
+	return a.Ident.Name == b.Ident.Name && identical(a.Type, b.Type) && equalValue(a.Value, b.Value)
+}
+
+func equalConsts(a, b []Const) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !equalConst(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalAnnotation(a, b Annotation) bool {
+	return a.Name == b.Name && a.Value == b.Value
+}
+
+func equalAnnotations(a, b []Annotation) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !equalAnnotation(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalEnumOption(a, b EnumOption) bool {
+	if (a.Value == nil) != (b.Value == nil) || a.Value != nil && *a.Value != *b.Value {
+		return false
+	}
+	return a.Ident.Name == b.Ident.Name && a.IsAll == b.IsAll && a.IsNone == b.IsNone
+}
+
+func equalField(a, b Field) bool {
+	return a.Ident.Name == b.Ident.Name &&
+		identical(a.Type, b.Type) &&
+		equalAnnotations(a.Annotations, b.Annotations) &&
+		a.Number == b.Number &&
+		equalValue(a.Default, b.Default)
+}
+
+func equalFields(a, b []Field) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !equalField(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalMethod(a, b Method) bool {
+	if (a.Return == nil) != (b.Return == nil) || a.Return != nil && !identical(*a.Return, *b.Return) {
+		return false
+	}
+	return a.Ident.Name == b.Ident.Name &&
+		equalFields(a.Params, b.Params) &&
+		a.Static == b.Static &&
+		a.Const == b.Const &&
+		a.Ext == b.Ext
+}
+
+func equalEnum(a, b Enum) bool {
+	if a.Flags != b.Flags || a.Deriving != b.Deriving || len(a.Options) != len(b.Options) {
+		return false
+	}
+	for i := range a.Options {
+		if !equalEnumOption(a.Options[i], b.Options[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalRecord(a, b Record) bool {
+	return a.Ext == b.Ext &&
+		a.Deriving == b.Deriving &&
+		equalFields(a.Fields, b.Fields) &&
+		equalConsts(a.Consts, b.Consts)
+}
+
+func equalInterface(a, b Interface) bool {
+	if a.Ext != b.Ext || len(a.Methods) != len(b.Methods) {
+		return false
+	}
+	for i := range a.Methods {
+		if !equalMethod(a.Methods[i], b.Methods[i]) {
+			return false
+		}
+	}
+	return equalConsts(a.Consts, b.Consts)
+}
+
+func equalTypeDecl(a, b TypeDecl) bool {
+	return a.Ident.Name == b.Ident.Name &&
+		equalAnnotations(a.Annotations, b.Annotations) &&
+		Equal(a.Body, b.Body)
+}
+
+func equalFile(a, b *IDLFile) bool {
+	if !equalStrings(a.Imports, b.Imports) ||
+		!equalStrings(a.Externs, b.Externs) ||
+		!equalStrings(a.Namespace, b.Namespace) ||
+		len(a.TypeDecls) != len(b.TypeDecls) ||
+		len(a.Imported) != len(b.Imported) {
+		return false
+	}
+	for i := range a.TypeDecls {
+		if !equalTypeDecl(a.TypeDecls[i], b.TypeDecls[i]) {
+			return false
+		}
+	}
+	for i := range a.Imported {
+		if !Equal(a.Imported[i], b.Imported[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// equalValue reports whether the constant values a and b, see Const, are
+// identical. An int64 and a float64 are never equal.
+func equalValue(a, b interface{}) bool {
+	switch a := a.(type) {
+	case Ref:
+		b, ok := b.(Ref)
+		if !ok || a.Ident.Name != b.Ident.Name || (a.Scope == nil) != (b.Scope == nil) {
+			return false
+		}
+		return a.Scope == nil || a.Scope.Name == b.Scope.Name
+	case RecordLiteral:
+		b, ok := b.(RecordLiteral)
+		if !ok || len(a.Fields) != len(b.Fields) {
+			return false
+		}
+		for i := range a.Fields {
+			if a.Fields[i].Ident.Name != b.Fields[i].Ident.Name || !equalValue(a.Fields[i].Value, b.Fields[i].Value) {
+				return false
+			}
+		}
+		return true
+	case MapValue:
+		b, ok := b.(MapValue)
+		if !ok || len(a.Entries) != len(b.Entries) {
+			return false
+		}
+		for i := range a.Entries {
+			if !equalValue(a.Entries[i].Key, b.Entries[i].Key) || !equalValue(a.Entries[i].Value, b.Entries[i].Value) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equalValue(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case nil, int64, float64, string, bool, NullValue:
+		return a == b
+	}
+	return false
+}
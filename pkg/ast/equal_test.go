@@ -0,0 +1,65 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/ast"
+	"github.com/SafetyCulture/djinni-parser/pkg/parser"
+)
+
+func TestEqual(t *testing.T) {
+	t.Parallel()
+
+	parse := func(src string) *ast.IDLFile {
+		f, err := parser.ParseFile("", src, parser.WithComments(), parser.WithRawSource())
+		if err != nil {
+			t.Fatalf("%q: %v", src, err)
+		}
+		return f
+	}
+
+	base := `my_record = record { id: i32; tags: list<string> = ["a"]; const c: my_enum = my_enum.a; } deriving (eq)`
+	tests := [...]struct {
+		name string
+		src  string
+		want bool
+	}{
+		{"Identical", base, true},
+		{"Layout", "# a record\nmy_record = record {\n\tid:   i32; # the id\n\ttags: list< string > = [ \"a\", ];\n\tconst c: my_enum = my_enum.a;\n} deriving (eq)", true},
+		{"FieldType", `my_record = record { id: i64; tags: list<string> = ["a"]; const c: my_enum = my_enum.a; } deriving (eq)`, false},
+		{"TypeArg", `my_record = record { id: i32; tags: set<string> = ["a"]; const c: my_enum = my_enum.a; } deriving (eq)`, false},
+		{"Default", `my_record = record { id: i32; tags: list<string> = ["b"]; const c: my_enum = my_enum.a; } deriving (eq)`, false},
+		{"RefScope", `my_record = record { id: i32; tags: list<string> = ["a"]; const c: my_enum = a; } deriving (eq)`, false},
+		{"Deriving", `my_record = record { id: i32; tags: list<string> = ["a"]; const c: my_enum = my_enum.a; }`, false},
+		{"FieldOrder", `my_record = record { tags: list<string> = ["a"]; id: i32; const c: my_enum = my_enum.a; } deriving (eq)`, false},
+		{"Name", `other = record { id: i32; tags: list<string> = ["a"]; const c: my_enum = my_enum.a; } deriving (eq)`, false},
+	}
+
+	a := parse(base)
+	for _, tt := range tests {
+		b := parse(tt.src)
+		if got := ast.Equal(a, b); got != tt.want {
+			t.Errorf("%s: files: expected %v, got %v", tt.name, tt.want, got)
+		}
+		if got := ast.Equal(&a.TypeDecls[0], &b.TypeDecls[0]); got != tt.want {
+			t.Errorf("%s: declarations: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	i32 := &ast.TypeExpr{Ident: ast.Ident{Name: "i32"}}
+	if ast.Equal(i32, &ast.Ident{Name: "i32"}) {
+		t.Error("nodes of different types should not be equal")
+	}
+	if !ast.Equal(i32, &ast.TypeExpr{Ident: ast.Ident{Name: "i32"}}) || ast.Equal(i32, nil) || !ast.Equal(nil, nil) {
+		t.Error("incorrect result for type expressions")
+	}
+	one, other := 1, 1
+	if !ast.Equal(&ast.EnumOption{Value: &one}, &ast.EnumOption{Value: &other}) {
+		t.Error("options with equal values should be equal")
+	}
+	ints := &ast.Const{Type: *i32, Value: int64(1)}
+	floats := &ast.Const{Type: *i32, Value: float64(1)}
+	if ast.Equal(ints, floats) {
+		t.Error("an int and a float value should not be equal")
+	}
+}
@@ -0,0 +1,290 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// The interface-typed fields of the AST, TypeDecl.Body and the values of
+// constants, are encoded in JSON together with the name of their concrete
+// type, so that decoding can reconstruct the tree.
+
+type typeDeclAlias TypeDecl
+
+// MarshalJSON implements the json.Marshaler interface. The Kind of the
+// body, one of "enum", "record", "interface" or "bad", is added.
+func (d TypeDecl) MarshalJSON() ([]byte, error) {
+	var kind string
+	switch d.Body.(type) {
+	case nil:
+	case *Enum:
+		kind = "enum"
+	case *Record:
+		kind = "record"
+	case *Interface:
+		kind = "interface"
+	case *BadDef:
+		kind = "bad"
+	default:
+		return nil, fmt.Errorf("ast: cannot marshal type definition %T", d.Body)
+	}
+	return json.Marshal(struct {
+		typeDeclAlias
+		Kind string `json:",omitempty"`
+	}{typeDeclAlias(d), kind})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (d *TypeDecl) UnmarshalJSON(b []byte) error {
+	v := struct {
+		*typeDeclAlias
+		Kind string
+		Body json.RawMessage
+	}{typeDeclAlias: (*typeDeclAlias)(d)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	switch v.Kind {
+	case "":
+		d.Body = nil
+		return nil
+	case "enum":
+		d.Body = &Enum{}
+	case "record":
+		d.Body = &Record{}
+	case "interface":
+		d.Body = &Interface{}
+	case "bad":
+		d.Body = &BadDef{}
+	default:
+		return fmt.Errorf("ast: unknown type definition kind %q", v.Kind)
+	}
+	return json.Unmarshal(v.Body, d.Body)
+}
+
+// jsonValue is the JSON encoding of the value of a constant.
+type jsonValue struct {
+	Type  string // "int", "float", "string", "bool", "null", "ref", "record", "map" or "list"
+	Value json.RawMessage
+}
+
+func encodeValue(v interface{}) (*jsonValue, error) {
+	var typ string
+	switch v.(type) {
+	case nil:
+		return nil, nil
+	case int64:
+		typ = "int"
+	case float64:
+		typ = "float"
+	case string:
+		typ = "string"
+	case bool:
+		typ = "bool"
+	case NullValue:
+		typ = "null"
+	case Ref:
+		typ = "ref"
+	case RecordLiteral:
+		typ = "record"
+	case MapValue:
+		typ = "map"
+	case []interface{}:
+		typ = "list"
+		list := v.([]interface{})
+		elems := make([]*jsonValue, len(list))
+		for i, e := range list {
+			j, err := encodeValue(e)
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = j
+		}
+		v = elems
+	default:
+		return nil, fmt.Errorf("ast: cannot marshal constant value %T", v)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonValue{typ, b}, nil
+}
+
+func decodeValue(j *jsonValue) (interface{}, error) {
+	if j == nil {
+		return nil, nil
+	}
+	var v interface{}
+	var err error
+	switch j.Type {
+	case "int":
+		var i int64
+		err = json.Unmarshal(j.Value, &i)
+		v = i
+	case "float":
+		var f float64
+		err = json.Unmarshal(j.Value, &f)
+		v = f
+	case "string":
+		var s string
+		err = json.Unmarshal(j.Value, &s)
+		v = s
+	case "bool":
+		var b bool
+		err = json.Unmarshal(j.Value, &b)
+		v = b
+	case "null":
+		v = NullValue{}
+	case "ref":
+		var r Ref
+		err = json.Unmarshal(j.Value, &r)
+		v = r
+	case "record":
+		var r RecordLiteral
+		err = json.Unmarshal(j.Value, &r)
+		v = r
+	case "map":
+		var m MapValue
+		err = json.Unmarshal(j.Value, &m)
+		v = m
+	case "list":
+		var elems []*jsonValue
+		if err := json.Unmarshal(j.Value, &elems); err != nil {
+			return nil, err
+		}
+		list := make([]interface{}, len(elems))
+		for i, e := range elems {
+			if list[i], err = decodeValue(e); err != nil {
+				return nil, err
+			}
+		}
+		v = list
+	default:
+		return nil, fmt.Errorf("ast: unknown constant value type %q", j.Type)
+	}
+	return v, err
+}
+
+type constAlias Const
+
+// MarshalJSON implements the json.Marshaler interface.
+func (c Const) MarshalJSON() ([]byte, error) {
+	v, err := encodeValue(c.Value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		constAlias
+		Value *jsonValue
+	}{constAlias(c), v})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (c *Const) UnmarshalJSON(b []byte) error {
+	v := struct {
+		*constAlias
+		Value *jsonValue
+	}{constAlias: (*constAlias)(c)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	var err error
+	c.Value, err = decodeValue(v.Value)
+	return err
+}
+
+type fieldAlias Field
+
+// MarshalJSON implements the json.Marshaler interface.
+func (f Field) MarshalJSON() ([]byte, error) {
+	v, err := encodeValue(f.Default)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		fieldAlias
+		Default *jsonValue
+	}{fieldAlias(f), v})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (f *Field) UnmarshalJSON(b []byte) error {
+	v := struct {
+		*fieldAlias
+		Default *jsonValue
+	}{fieldAlias: (*fieldAlias)(f)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	var err error
+	f.Default, err = decodeValue(v.Default)
+	return err
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (f FieldValue) MarshalJSON() ([]byte, error) {
+	v, err := encodeValue(f.Value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		Ident Ident
+		Value *jsonValue
+	}{f.Ident, v})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (f *FieldValue) UnmarshalJSON(b []byte) error {
+	var v struct {
+		Ident Ident
+		Value *jsonValue
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	value, err := decodeValue(v.Value)
+	if err != nil {
+		return err
+	}
+	*f = FieldValue{Ident: v.Ident, Value: value}
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (e MapEntry) MarshalJSON() ([]byte, error) {
+	k, err := encodeValue(e.Key)
+	if err != nil {
+		return nil, err
+	}
+	v, err := encodeValue(e.Value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		Key   *jsonValue
+		Value *jsonValue
+	}{k, v})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (e *MapEntry) UnmarshalJSON(b []byte) error {
+	var v struct {
+		Key   *jsonValue
+		Value *jsonValue
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	key, err := decodeValue(v.Key)
+	if err != nil {
+		return err
+	}
+	value, err := decodeValue(v.Value)
+	if err != nil {
+		return err
+	}
+	*e = MapEntry{Key: key, Value: value}
+	return nil
+}
@@ -0,0 +1,53 @@
+package ast_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/ast"
+	"github.com/SafetyCulture/djinni-parser/pkg/parser"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+	src := `
+		@import "common.djinni"
+
+		# a record
+		my_record = @json record +c {
+			id: i32;
+			score: f64 = 0.5;
+			const max: i64 = 9007199254740993;
+			const ratio: f64 = 2.0;
+			const name: string = "name";
+			const none: optional<i32> = null;
+			const enabled: bool = false;
+			const origin: point = { x = 1, y = { z = "z" } };
+			const lookup: map<string, map<i32, f64>> = { "a": { 1: 1.5 } };
+			const first: my_enum = my_enum.first;
+			const limit: i64 = max;
+			const primes: list<i32> = [2, 3, 5];
+			const empty: set<string> = [];
+		} deriving (eq)
+		my_enum = enum { first; }
+		my_interface = interface +o { get(key: string): optional<my_record>; const version: i32 = 1; }
+		bad = struct {}
+	`
+
+	f, _ := parser.ParseFile("", src, parser.WithComments())
+
+	b, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got ast.IDLFile
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(f, &got); diff != "" {
+		t.Errorf("incorrect tree after a JSON round trip:\n%s", diff)
+	}
+}
@@ -0,0 +1,69 @@
+package ast
+
+import "strconv"
+
+// Kind identifies the type of a node, see Node.Kind.
+type Kind int
+
+// The kinds of nodes, one for each node type.
+const (
+	KindInvalid Kind = iota // not a node
+	KindComment
+	KindCommentGroup
+	KindIdent
+	KindConst
+	KindAnnotation
+	KindEnumOption
+	KindTypeExpr
+	KindField
+	KindMethod
+	KindEnum // enums and flags
+	KindRecord
+	KindInterface
+	KindBadDef
+	KindTypeDecl
+	KindFile
+)
+
+var kinds = [...]string{
+	KindInvalid:      "Invalid",
+	KindComment:      "Comment",
+	KindCommentGroup: "CommentGroup",
+	KindIdent:        "Ident",
+	KindConst:        "Const",
+	KindAnnotation:   "Annotation",
+	KindEnumOption:   "EnumOption",
+	KindTypeExpr:     "TypeExpr",
+	KindField:        "Field",
+	KindMethod:       "Method",
+	KindEnum:         "Enum",
+	KindRecord:       "Record",
+	KindInterface:    "Interface",
+	KindBadDef:       "BadDef",
+	KindTypeDecl:     "TypeDecl",
+	KindFile:         "File",
+}
+
+// String returns the name of the node type of the kind, e.g. "Record".
+func (k Kind) String() string {
+	if 0 <= k && k < Kind(len(kinds)) {
+		return kinds[k]
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+func (*Comment) Kind() Kind      { return KindComment }
+func (*CommentGroup) Kind() Kind { return KindCommentGroup }
+func (*Ident) Kind() Kind        { return KindIdent }
+func (*Const) Kind() Kind        { return KindConst }
+func (*Annotation) Kind() Kind   { return KindAnnotation }
+func (*EnumOption) Kind() Kind   { return KindEnumOption }
+func (*TypeExpr) Kind() Kind     { return KindTypeExpr }
+func (*Field) Kind() Kind        { return KindField }
+func (*Method) Kind() Kind       { return KindMethod }
+func (*Enum) Kind() Kind         { return KindEnum }
+func (*Record) Kind() Kind       { return KindRecord }
+func (*Interface) Kind() Kind    { return KindInterface }
+func (*BadDef) Kind() Kind       { return KindBadDef }
+func (*TypeDecl) Kind() Kind     { return KindTypeDecl }
+func (*IDLFile) Kind() Kind      { return KindFile }
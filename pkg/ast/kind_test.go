@@ -0,0 +1,65 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/ast"
+	"github.com/SafetyCulture/djinni-parser/pkg/parser"
+)
+
+func TestKind(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		node ast.Node
+		want ast.Kind
+		name string
+	}{
+		{&ast.Comment{}, ast.KindComment, "Comment"},
+		{&ast.CommentGroup{}, ast.KindCommentGroup, "CommentGroup"},
+		{&ast.Ident{}, ast.KindIdent, "Ident"},
+		{&ast.Const{}, ast.KindConst, "Const"},
+		{&ast.Annotation{}, ast.KindAnnotation, "Annotation"},
+		{&ast.EnumOption{}, ast.KindEnumOption, "EnumOption"},
+		{&ast.TypeExpr{}, ast.KindTypeExpr, "TypeExpr"},
+		{&ast.Field{}, ast.KindField, "Field"},
+		{&ast.Method{}, ast.KindMethod, "Method"},
+		{&ast.Enum{}, ast.KindEnum, "Enum"},
+		{&ast.Enum{Flags: true}, ast.KindEnum, "Enum"},
+		{&ast.Record{}, ast.KindRecord, "Record"},
+		{&ast.Interface{}, ast.KindInterface, "Interface"},
+		{&ast.BadDef{}, ast.KindBadDef, "BadDef"},
+		{&ast.TypeDecl{}, ast.KindTypeDecl, "TypeDecl"},
+		{&ast.IDLFile{}, ast.KindFile, "File"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.node.Kind(); got != tt.want || got.String() != tt.name {
+			t.Errorf("%T: expected %s, got %s", tt.node, tt.name, got)
+		}
+	}
+	if s := ast.Kind(-1).String(); s != "Kind(-1)" {
+		t.Errorf("incorrect string for an unknown kind: %s", s)
+	}
+}
+
+func TestKindFilter(t *testing.T) {
+	t.Parallel()
+	f, err := parser.ParseFile("", "a = record { id: i32; name: string; } b = interface +c { get(key: string): a; }")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fields []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n != nil && n.Kind() == ast.KindField {
+			fields = append(fields, n.(*ast.Field).Ident.Name)
+		}
+		return true
+	})
+	if diff := cmp.Diff([]string{"id", "name", "key"}, fields); diff != "" {
+		t.Errorf("incorrect fields: %s", diff)
+	}
+}
@@ -0,0 +1,62 @@
+package ast
+
+import "github.com/SafetyCulture/djinni-parser/pkg/token"
+
+// FindReferences returns the positions of the type name in f, in source
+// order: the identifier of its declaration, every type expression that
+// refers to it, including type arguments, consts and method signatures,
+// and the scope of every constant value qualified by it, as in color.red.
+// Fields, options and methods with the same name are not references.
+func FindReferences(f *IDLFile, name string) []token.Pos {
+	var refs []token.Pos
+	var find func(n Node) bool
+	find = func(n Node) bool {
+		switch n := n.(type) {
+		case *TypeDecl:
+			if n.Ident.Name == name {
+				refs = append(refs, n.Ident.Pos)
+			}
+		case *TypeExpr:
+			if n.Ident.Name == name {
+				refs = append(refs, n.Ident.Pos)
+			}
+		case *Field:
+			// the default value follows the type
+			Inspect(&n.Type, find)
+			refs = valueRefs(refs, n.Default, name)
+			return false
+		case *Const:
+			Inspect(&n.Type, find)
+			refs = valueRefs(refs, n.Value, name)
+			return false
+		}
+		return true
+	}
+	Inspect(f, find)
+	return refs
+}
+
+// valueRefs appends the positions of the scopes named name within the
+// constant value v, see Const, to refs.
+func valueRefs(refs []token.Pos, v interface{}, name string) []token.Pos {
+	switch v := v.(type) {
+	case Ref:
+		if v.Scope != nil && v.Scope.Name == name {
+			refs = append(refs, v.Scope.Pos)
+		}
+	case RecordLiteral:
+		for _, f := range v.Fields {
+			refs = valueRefs(refs, f.Value, name)
+		}
+	case MapValue:
+		for _, e := range v.Entries {
+			refs = valueRefs(refs, e.Key, name)
+			refs = valueRefs(refs, e.Value, name)
+		}
+	case []interface{}:
+		for _, e := range v {
+			refs = valueRefs(refs, e, name)
+		}
+	}
+	return refs
+}
@@ -0,0 +1,53 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/ast"
+	"github.com/SafetyCulture/djinni-parser/pkg/parser"
+	"github.com/SafetyCulture/djinni-parser/pkg/token"
+)
+
+func TestFindReferences(t *testing.T) {
+	t.Parallel()
+	src := "item = record { item: i32; }\n" +
+		"store = record { items: map<string, item>; }\n" +
+		"api = interface +c { get(id: i32): optional<item>; }\n"
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []token.Pos{
+		{Offset: 0, Line: 1, Column: 1},
+		{Offset: 65, Line: 2, Column: 37},
+		{Offset: 118, Line: 3, Column: 45},
+	}
+	if diff := cmp.Diff(want, ast.FindReferences(f, "item")); diff != "" {
+		t.Errorf("incorrect references: %s", diff)
+	}
+
+	if refs := ast.FindReferences(f, "unknown"); refs != nil {
+		t.Errorf("expected no references, got %v", refs)
+	}
+	src = "color = enum { red; }\n" +
+		"pair = record { c: color; }\n" +
+		"palette = record { const c: color = color.red; const p: pair = { c = color.red }; }\n"
+	f, err = parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []token.Pos{
+		{Offset: 0, Line: 1, Column: 1},
+		{Offset: 41, Line: 2, Column: 20},
+		{Offset: 78, Line: 3, Column: 29},
+		{Offset: 86, Line: 3, Column: 37},
+		{Offset: 119, Line: 3, Column: 70},
+	}
+	if diff := cmp.Diff(want, ast.FindReferences(f, "color")); diff != "" {
+		t.Errorf("incorrect references in constant values: %s", diff)
+	}
+}
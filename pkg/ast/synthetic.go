@@ -0,0 +1,44 @@
+package ast
+
+// SyntheticMembers returns the operations that code generators derive for
+// decl, for use in documentation: a deriving (eq) clause implies
+//
+//	const equals(other: T): bool;
+//
+// and a deriving (ord) clause implies
+//
+//	const compare_to(other: T): i32;
+//
+// where T is the declared type. The methods have no positions. Parcelable
+// only affects the Java serialization and implies no operations.
+func SyntheticMembers(decl TypeDecl) []Method {
+	var d Deriving
+	switch def := decl.Body.(type) {
+	case *Record:
+		d = def.Deriving
+	case *Enum:
+		d = def.Deriving
+	default:
+		return nil
+	}
+
+	self := TypeExpr{Ident: Ident{Name: decl.Ident.Name}}
+	synthetic := func(name, doc, ret string) Method {
+		return Method{
+			Doc:    &CommentGroup{List: []*Comment{{Text: "# " + doc}}},
+			Ident:  Ident{Name: name},
+			Params: []Field{{Ident: Ident{Name: "other"}, Type: self}},
+			Return: &TypeExpr{Ident: Ident{Name: ret}},
+			Const:  true,
+		}
+	}
+
+	var methods []Method
+	if d.Eq {
+		methods = append(methods, synthetic("equals", "Reports whether the value equals other.", "bool"))
+	}
+	if d.Ord {
+		methods = append(methods, synthetic("compare_to", "Compares the value to other, returning a negative number, zero or a positive number.", "i32"))
+	}
+	return methods
+}
@@ -0,0 +1,46 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/ast"
+)
+
+func TestSyntheticMembers(t *testing.T) {
+	t.Parallel()
+
+	decl := func(body ast.TypeDef) ast.TypeDecl {
+		return ast.TypeDecl{Ident: ast.Ident{Name: "version"}, Body: body}
+	}
+
+	tests := [...]struct {
+		name string
+		decl ast.TypeDecl
+		want []string
+	}{
+		{"Ord", decl(&ast.Record{Deriving: ast.Deriving{Ord: true}}), []string{"compare_to(other: version): i32"}},
+		{"EqOrd", decl(&ast.Record{Deriving: ast.Deriving{Eq: true, Ord: true}}), []string{"equals(other: version): bool", "compare_to(other: version): i32"}},
+		{"Parcelable", decl(&ast.Record{Deriving: ast.Deriving{Parcelable: true}}), nil},
+		{"Enum", decl(&ast.Enum{Deriving: ast.Deriving{Eq: true}}), []string{"equals(other: version): bool"}},
+		{"Interface", decl(&ast.Interface{}), nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, m := range ast.SyntheticMembers(tt.decl) {
+			if !m.Const || m.Doc.Text() == "" {
+				t.Errorf("%s: %s should be a documented const method", tt.name, m.Ident.Name)
+			}
+			got = append(got, m.Ident.Name+"("+m.Params[0].Ident.Name+": "+m.Params[0].Type.String()+"): "+m.Return.String())
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: incorrect members: expected %q, got %q", tt.name, tt.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: incorrect member: expected %s, got %s", tt.name, tt.want[i], got[i])
+			}
+		}
+	}
+}
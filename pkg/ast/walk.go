@@ -0,0 +1,151 @@
+package ast
+
+import "fmt"
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order: It starts by calling
+// v.Visit(node); node must not be nil. If the visitor w returned by
+// v.Visit(node) is not nil, Walk is invoked recursively with visitor
+// w for each of the non-nil children of node, followed by a call of
+// w.Visit(nil).
+//
+// Const values are plain Go values rather than nodes and are not visited.
+// Neither are the comments in IDLFile.Comments nor the imported files in
+// IDLFile.Imported; doc comments are visited with the node they document.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	// walk children
+	switch n := node.(type) {
+	// Comments
+	case *Comment:
+		// nothing to do
+
+	case *CommentGroup:
+		for _, c := range n.List {
+			Walk(v, c)
+		}
+
+	// Expressions
+	case *Ident, *Annotation, *BadDef:
+		// nothing to do
+
+	case *Const:
+		if n.Doc != nil {
+			Walk(v, n.Doc)
+		}
+		Walk(v, &n.Ident)
+		Walk(v, &n.Type)
+
+	case *EnumOption:
+		if n.Doc != nil {
+			Walk(v, n.Doc)
+		}
+		Walk(v, &n.Ident)
+		if n.Comment != nil {
+			Walk(v, n.Comment)
+		}
+
+	case *TypeExpr:
+		Walk(v, &n.Ident)
+		for i := range n.Args {
+			Walk(v, &n.Args[i])
+		}
+
+	case *Field:
+		if n.Doc != nil {
+			Walk(v, n.Doc)
+		}
+		for i := range n.Annotations {
+			Walk(v, &n.Annotations[i])
+		}
+		Walk(v, &n.Ident)
+		Walk(v, &n.Type)
+		if n.Comment != nil {
+			Walk(v, n.Comment)
+		}
+
+	case *Method:
+		if n.Doc != nil {
+			Walk(v, n.Doc)
+		}
+		Walk(v, &n.Ident)
+		for i := range n.Params {
+			Walk(v, &n.Params[i])
+		}
+		if n.Return != nil {
+			Walk(v, n.Return)
+		}
+
+	// Type definitions
+	case *Enum:
+		for i := range n.Options {
+			Walk(v, &n.Options[i])
+		}
+
+	case *Record:
+		for i := range n.Fields {
+			Walk(v, &n.Fields[i])
+		}
+		for i := range n.Consts {
+			Walk(v, &n.Consts[i])
+		}
+
+	case *Interface:
+		for i := range n.Methods {
+			Walk(v, &n.Methods[i])
+		}
+		for i := range n.Consts {
+			Walk(v, &n.Consts[i])
+		}
+
+	// Declarations
+	case *TypeDecl:
+		if n.Doc != nil {
+			Walk(v, n.Doc)
+		}
+		Walk(v, &n.Ident)
+		for i := range n.Annotations {
+			Walk(v, &n.Annotations[i])
+		}
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+
+	// Files
+	case *IDLFile:
+		for i := range n.TypeDecls {
+			Walk(v, &n.TypeDecls[i])
+		}
+
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order: It starts by calling
+// f(node); node must not be nil. If f returns true, Inspect invokes f
+// recursively for each of the non-nil children of node, followed by a
+// call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
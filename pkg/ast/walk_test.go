@@ -0,0 +1,92 @@
+package ast_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/ast"
+	"github.com/SafetyCulture/djinni-parser/pkg/parser"
+)
+
+// recorder records every visited node, with the name of identifiers.
+type recorder []string
+
+func (r *recorder) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case nil:
+		return nil
+	case *ast.Ident:
+		*r = append(*r, "Ident "+n.Name)
+	default:
+		*r = append(*r, fmt.Sprintf("%T", n)[len("*ast."):])
+	}
+	return r
+}
+
+func TestWalk(t *testing.T) {
+	t.Parallel()
+	src := `
+		my_record = record { id: list<i32>; }
+		my_enum = enum { first; }
+		my_interface = interface +c { get(key: string): my_record; }
+	`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got recorder
+	ast.Walk(&got, f)
+
+	want := recorder{
+		"IDLFile",
+		"TypeDecl", "Ident my_record", "Record",
+		"Field", "Ident id", "TypeExpr", "Ident list", "TypeExpr", "Ident i32",
+		"TypeDecl", "Ident my_enum", "Enum",
+		"EnumOption", "Ident first",
+		"TypeDecl", "Ident my_interface", "Interface",
+		"Method", "Ident get",
+		"Field", "Ident key", "TypeExpr", "Ident string",
+		"TypeExpr", "Ident my_record",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("incorrect visit order: %s", diff)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	t.Parallel()
+	src := `
+		my_record = record {
+			id: i32;
+			name: optional<string>;
+			tags: list<optional<string>>;
+		}
+		my_interface = interface +c { find(name: optional<string>): my_record; }
+	`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Field:
+			if n.Type.Ident.Name == "optional" {
+				got = append(got, n.Ident.Name)
+			}
+		case *ast.Interface:
+			return false
+		}
+		return true
+	})
+
+	if diff := cmp.Diff([]string{"name"}, got); diff != "" {
+		t.Errorf("incorrect optional fields: %s", diff)
+	}
+}
@@ -0,0 +1,97 @@
+// Package diagram renders Djinni IDL files as diagrams.
+//
+package diagram
+
+import (
+	"strings"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/ast"
+)
+
+// Mermaid returns a Mermaid class diagram of the declarations in f. Every
+// record, enum, flags and interface becomes a class. Records list their
+// fields as attributes, enums their options, and interfaces their methods
+// as operations. A record field of a type declared in f, or in a file
+// imported by f, adds a composition edge; a method parameter or return
+// type adds a dependency edge. A type referring to itself has no edge.
+func Mermaid(f *ast.IDLFile) string {
+	declared := make(map[string]bool)
+	for _, d := range f.AllTypeDecls() {
+		declared[d.Ident.Name] = true
+	}
+
+	var b strings.Builder
+	var edges []string
+	seen := make(map[string]bool)
+	edge := func(from, arrow string, typ ast.TypeExpr) {
+		for _, to := range typeNames(typ) {
+			e := from + " " + arrow + " " + to
+			if declared[to] && to != from && !seen[e] {
+				seen[e] = true
+				edges = append(edges, e)
+			}
+		}
+	}
+
+	b.WriteString("classDiagram\n")
+	for _, d := range f.TypeDecls {
+		name := d.Ident.Name
+		switch def := d.Body.(type) {
+		case *ast.Record:
+			b.WriteString("    class " + name + " {\n        <<record>>\n")
+			for _, field := range def.Fields {
+				b.WriteString("        +" + mermaidType(field.Type) + " " + field.Ident.Name + "\n")
+				edge(name, "*--", field.Type)
+			}
+		case *ast.Enum:
+			stereotype := "enum"
+			if def.Flags {
+				stereotype = "flags"
+			}
+			b.WriteString("    class " + name + " {\n        <<" + stereotype + ">>\n")
+			for _, o := range def.Options {
+				b.WriteString("        " + o.Ident.Name + "\n")
+			}
+		case *ast.Interface:
+			b.WriteString("    class " + name + " {\n        <<interface>>\n")
+			for _, m := range def.Methods {
+				params := make([]string, len(m.Params))
+				for i, p := range m.Params {
+					params[i] = mermaidType(p.Type) + " " + p.Ident.Name
+					edge(name, "..>", p.Type)
+				}
+				b.WriteString("        +" + m.Ident.Name + "(" + strings.Join(params, ", ") + ")")
+				if m.Static {
+					b.WriteString("$")
+				}
+				if m.Return != nil {
+					b.WriteString(" " + mermaidType(*m.Return))
+					edge(name, "..>", *m.Return)
+				}
+				b.WriteString("\n")
+			}
+		default:
+			continue
+		}
+		b.WriteString("    }\n")
+	}
+	for _, e := range edges {
+		b.WriteString("    " + e + "\n")
+	}
+	return b.String()
+}
+
+// mermaidType returns typ with Mermaid's ~ in place of angle brackets,
+// e.g. map~string, list~i32~~.
+func mermaidType(typ ast.TypeExpr) string {
+	return strings.NewReplacer("<", "~", ">", "~").Replace(typ.String())
+}
+
+// typeNames returns the names of typ and its type arguments.
+func typeNames(typ ast.TypeExpr) []string {
+	names := []string{typ.Ident.Name}
+	for _, arg := range typ.Args {
+		names = append(names, typeNames(arg)...)
+	}
+	return names
+}
@@ -0,0 +1,51 @@
+package diagram_test
+
+import (
+	"testing"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/diagram"
+	"github.com/SafetyCulture/djinni-parser/pkg/parser"
+)
+
+func TestMermaid(t *testing.T) {
+	t.Parallel()
+	src := `
+		address = record { street: string; }
+		person = record { name: string; homes: list<address>; work: optional<address>; }
+		kind = enum { private; business; }
+		directory = interface +c { static create(): directory; find(name: string): optional<person>; }
+	`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `classDiagram
+    class address {
+        <<record>>
+        +string street
+    }
+    class person {
+        <<record>>
+        +string name
+        +list~address~ homes
+        +optional~address~ work
+    }
+    class kind {
+        <<enum>>
+        private
+        business
+    }
+    class directory {
+        <<interface>>
+        +create()$ directory
+        +find(string name) optional~person~
+    }
+    person *-- address
+    directory ..> person
+`
+	if got := diagram.Mermaid(f); got != want {
+		t.Errorf("incorrect diagram:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
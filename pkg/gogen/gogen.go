@@ -0,0 +1,315 @@
+// Package gogen generates Go type definitions from Djinni IDL files.
+//
+package gogen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/ast"
+)
+
+// Generate returns the source of a Go file in package pkg with a type for
+// each record, enum and flags declared in f. Interfaces are skipped.
+//
+// Names are converted to Go's exported camel case, e.g. my_record becomes
+// MyRecord. Records become structs whose fields have JSON tags of their
+// Djinni names, and constants of a basic or enum value become Go constants
+// prefixed with the record name. Enums become an int type and flags a
+// uint32 type, with a constant per option. Djinni types map as follows:
+//
+//	i8, i16, i32, i64  int8, int16, int32, int64
+//	f32, f64           float32, float64
+//	bool, string       bool, string
+//	binary             []byte
+//	date               time.Time
+//	list<T>            []T
+//	set<T>             map[T]struct{}
+//	map<K, V>          map[K]V
+//	optional<T>        *T
+//
+// A type that is neither one of these nor a record or enum declared in f
+// or its imported files, or a map key or set element that is not
+// comparable in Go, is an error.
+func Generate(f *ast.IDLFile, pkg string) ([]byte, error) {
+	g := generator{types: make(map[string]ast.TypeDef)}
+	for _, decl := range f.AllTypeDecls() {
+		g.types[decl.Ident.Name] = decl.Body
+	}
+
+	for _, decl := range f.TypeDecls {
+		var err error
+		switch def := decl.Body.(type) {
+		case *ast.Record:
+			err = g.record(&decl, def)
+		case *ast.Enum:
+			g.enum(&decl, def)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var src bytes.Buffer
+	src.WriteString("// Code generated by djinni-parser. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n\n", pkg)
+	if g.time {
+		src.WriteString("import \"time\"\n\n")
+	}
+	src.Write(g.Bytes())
+
+	out, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("gogen: formatting the generated source: %w", err)
+	}
+	return out, nil
+}
+
+type generator struct {
+	bytes.Buffer
+	types map[string]ast.TypeDef // declared types by Djinni name
+	time  bool                   // whether the time package is used
+}
+
+func (g *generator) record(decl *ast.TypeDecl, r *ast.Record) error {
+	name := goName(decl.Ident.Name)
+	g.doc(decl.Doc)
+	fmt.Fprintf(g, "type %s struct {\n", name)
+	for _, f := range r.Fields {
+		typ, err := g.goType(f.Type)
+		if err != nil {
+			return fmt.Errorf("gogen: %s.%s: %w", decl.Ident.Name, f.Ident.Name, err)
+		}
+		tag := f.Ident.Name
+		if f.Type.Ident.Name == "optional" {
+			tag += ",omitempty"
+		}
+		g.doc(f.Doc)
+		fmt.Fprintf(g, "%s %s `json:%q`\n", goName(f.Ident.Name), typ, tag)
+	}
+	g.WriteString("}\n\n")
+
+	for _, c := range r.Consts {
+		v, ok := g.constValue(name, r, c)
+		if !ok {
+			continue
+		}
+		typ, err := g.goType(c.Type)
+		if err != nil {
+			return fmt.Errorf("gogen: %s.%s: %w", decl.Ident.Name, c.Ident.Name, err)
+		}
+		g.doc(c.Doc)
+		fmt.Fprintf(g, "const %s%s %s = %s\n\n", name, goName(c.Ident.Name), typ, v)
+	}
+	return nil
+}
+
+func (g *generator) enum(decl *ast.TypeDecl, e *ast.Enum) {
+	name := goName(decl.Ident.Name)
+	g.doc(decl.Doc)
+	if e.Flags {
+		fmt.Fprintf(g, "type %s uint32\n\n", name)
+	} else {
+		fmt.Fprintf(g, "type %s int\n\n", name)
+	}
+	if len(e.Options) == 0 {
+		return
+	}
+
+	var all []string
+	g.WriteString("const (\n")
+	next, bit := 0, 0
+	for _, o := range e.Options {
+		var v string
+		switch {
+		case o.Value != nil:
+			v = strconv.Itoa(*o.Value)
+			next = *o.Value + 1
+		case o.IsNone:
+			v = "0"
+		case o.IsAll:
+			v = strings.Join(all, " | ")
+			if v == "" {
+				v = "0"
+			}
+		case e.Flags:
+			v = "1 << " + strconv.Itoa(bit)
+			bit++
+		default:
+			v = strconv.Itoa(next)
+			next++
+		}
+		option := name + goName(o.Ident.Name)
+		if e.Flags && !o.IsAll && !o.IsNone {
+			all = append(all, option)
+		}
+		g.doc(o.Doc)
+		fmt.Fprintf(g, "%s %s = %s\n", option, name, v)
+	}
+	g.WriteString(")\n\n")
+}
+
+// goType returns the Go type for typ.
+func (g *generator) goType(typ ast.TypeExpr) (string, error) {
+	args := make([]string, len(typ.Args))
+	for i, arg := range typ.Args {
+		s, err := g.goType(arg)
+		if err != nil {
+			return "", err
+		}
+		args[i] = s
+	}
+
+	want := 0
+	var s string
+	switch name := typ.Ident.Name; name {
+	case "i8", "i16", "i32", "i64":
+		s = "int" + name[1:]
+	case "f32", "f64":
+		s = "float" + name[1:]
+	case "bool", "string":
+		s = name
+	case "binary":
+		s = "[]byte"
+	case "date":
+		g.time = true
+		s = "time.Time"
+	case "list":
+		want = 1
+		if len(args) == want {
+			s = "[]" + args[0]
+		}
+	case "set":
+		want = 1
+		if len(args) == want {
+			if !g.comparable(typ.Args[0], nil) {
+				return "", fmt.Errorf("set element %s is not comparable in Go", typ.Args[0])
+			}
+			s = "map[" + args[0] + "]struct{}"
+		}
+	case "map":
+		want = 2
+		if len(args) == want {
+			if !g.comparable(typ.Args[0], nil) {
+				return "", fmt.Errorf("map key %s is not comparable in Go", typ.Args[0])
+			}
+			s = "map[" + args[0] + "]" + args[1]
+		}
+	case "optional":
+		want = 1
+		if len(args) == want {
+			s = "*" + args[0]
+		}
+	default:
+		switch g.types[name].(type) {
+		case *ast.Record, *ast.Enum:
+			s = goName(name)
+		case *ast.Interface:
+			return "", fmt.Errorf("interface type %s is not supported", name)
+		default:
+			return "", fmt.Errorf("unknown type %s", name)
+		}
+	}
+	if len(args) != want {
+		return "", fmt.Errorf("%s takes %d type arguments, got %d", typ.Ident.Name, want, len(args))
+	}
+	return s, nil
+}
+
+// comparable reports whether the Go type for typ is comparable, and thus
+// can be a map key. Records in seen are being checked already.
+func (g *generator) comparable(typ ast.TypeExpr, seen map[string]bool) bool {
+	switch name := typ.Ident.Name; name {
+	case "binary", "list", "set", "map":
+		return false
+	case "optional":
+		return true // a pointer
+	}
+	r, ok := g.types[typ.Ident.Name].(*ast.Record)
+	if !ok || seen[typ.Ident.Name] {
+		return true
+	}
+	if seen == nil {
+		seen = make(map[string]bool)
+	}
+	seen[typ.Ident.Name] = true
+	for _, f := range r.Fields {
+		if !g.comparable(f.Type, seen) {
+			return false
+		}
+	}
+	return true
+}
+
+// constValue returns the Go constant expression for the value of c, a
+// constant of r named name in Go, and whether c can be a Go constant.
+func (g *generator) constValue(name string, r *ast.Record, c ast.Const) (string, bool) {
+	switch c.Type.Ident.Name {
+	case "i8", "i16", "i32", "i64", "f32", "f64", "bool", "string":
+	default:
+		if _, ok := g.types[c.Type.Ident.Name].(*ast.Enum); !ok {
+			return "", false
+		}
+	}
+
+	switch v := c.Value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), true
+	case string:
+		return strconv.Quote(v), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case ast.Ref:
+		if v.Scope == nil {
+			// only earlier constants, so that a cycle ends
+			for _, other := range r.Consts {
+				if other.Ident.Name == v.Ident.Name && other.Pos.Offset < c.Pos.Offset {
+					if _, ok := g.constValue(name, r, other); ok {
+						return name + goName(v.Ident.Name), true
+					}
+				}
+			}
+			return "", false
+		}
+		if e, ok := g.types[v.Scope.Name].(*ast.Enum); ok {
+			for _, o := range e.Options {
+				if o.Ident.Name == v.Ident.Name {
+					return goName(v.Scope.Name) + goName(v.Ident.Name), true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+func (g *generator) doc(c *ast.CommentGroup) {
+	text := c.Text()
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		g.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+	}
+}
+
+// goName converts a Djinni name to an exported Go name, e.g. my_record to
+// MyRecord.
+func goName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part == "" {
+			continue
+		}
+		r, n := utf8.DecodeRuneInString(part)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(part[n:])
+	}
+	return b.String()
+}
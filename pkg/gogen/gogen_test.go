@@ -0,0 +1,124 @@
+package gogen_test
+
+import (
+	"testing"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/gogen"
+	"github.com/SafetyCulture/djinni-parser/pkg/parser"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+	src := `
+		# A color.
+		color = enum {
+			red;
+			green = 5;
+			blue;
+		}
+
+		access = flags {
+			read;
+			write;
+			nothing = none;
+			everything = all;
+		}
+
+		# A person.
+		person = record {
+			# the id
+			id: i64;
+			display_name: string;
+			avatar: optional<binary>;
+			born: date;
+			scores: map<string, list<f32>>;
+			favorite: set<color>;
+			const max_scores: i32 = 10;
+			const limit: i32 = max_scores;
+			const default_color: color = color.red;
+			const origin: person = { id = 1 };
+		}
+
+		directory = interface +c { find(id: i64): person; }
+	`
+
+	f, err := parser.ParseFile("", src, parser.WithComments())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := gogen.Generate(f, "model")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "// Code generated by djinni-parser. DO NOT EDIT.\n" + `
+package model
+
+import "time"
+
+// A color.
+type Color int
+
+const (
+	ColorRed   Color = 0
+	ColorGreen Color = 5
+	ColorBlue  Color = 6
+)
+
+type Access uint32
+
+const (
+	AccessRead       Access = 1 << 0
+	AccessWrite      Access = 1 << 1
+	AccessNothing    Access = 0
+	AccessEverything Access = AccessRead | AccessWrite
+)
+
+// A person.
+type Person struct {
+	// the id
+	Id          int64                ` + "`json:\"id\"`" + `
+	DisplayName string               ` + "`json:\"display_name\"`" + `
+	Avatar      *[]byte              ` + "`json:\"avatar,omitempty\"`" + `
+	Born        time.Time            ` + "`json:\"born\"`" + `
+	Scores      map[string][]float32 ` + "`json:\"scores\"`" + `
+	Favorite    map[Color]struct{}   ` + "`json:\"favorite\"`" + `
+}
+
+const PersonMaxScores int32 = 10
+
+const PersonLimit int32 = PersonMaxScores
+
+const PersonDefaultColor Color = ColorRed
+`
+	if string(got) != want {
+		t.Errorf("incorrect source:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name string
+		src  string
+		want string
+	}{
+		{"UnknownType", "r = record { a: addr; }", "gogen: r.a: unknown type addr"},
+		{"InterfaceType", "i = interface +c {}\nr = record { i: i; }", "gogen: r.i: interface type i is not supported"},
+		{"BinaryKey", "r = record { m: map<binary, i32>; }", "gogen: r.m: map key binary is not comparable in Go"},
+		{"RecordElement", "k = record { l: list<i32>; }\nr = record { s: set<k>; }", "gogen: r.s: set element k is not comparable in Go"},
+	}
+
+	for _, tt := range tests {
+		f, err := parser.ParseFile("", tt.src)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		_, err = gogen.Generate(f, "model")
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%s: incorrect error: %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Package jsonschema generates JSON Schema documents from Djinni IDL records.
+//
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/ast"
+)
+
+// draft is the JSON Schema dialect of the generated documents.
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+// schema is a JSON Schema object; encoding/json sorts its keys.
+type schema map[string]interface{}
+
+// FromRecord returns a JSON Schema document, titled name, that describes
+// the values of r as JSON objects. Each field becomes a property, and all
+// fields but the optional ones are required. Types map as follows:
+//
+//	i8, i16, i32, i64  integer
+//	f32, f64           number
+//	bool               boolean
+//	string             string
+//	binary             string with base64 content encoding
+//	date               string in date-time format
+//	list<T>            array of T
+//	set<T>             array of unique T
+//	map<K, V>          object of V
+//	optional<T>        T or null
+//
+// Any other type is referenced as a sibling document, e.g. {"$ref":
+// "address.json"} for a field of type address. A field default becomes the
+// property's default, and each constant with a JSON value becomes a
+// definition in $defs that only accepts that value.
+func FromRecord(r *ast.Record, name string) ([]byte, error) {
+	if r == nil {
+		return nil, fmt.Errorf("jsonschema: no record for %s", name)
+	}
+
+	properties := make(schema)
+	required := []string{}
+	for _, f := range r.Fields {
+		s, err := typeSchema(f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("jsonschema: field %s: %w", f.Ident.Name, err)
+		}
+		if v, ok := jsonValue(f.Default); ok && f.Default != nil {
+			s["default"] = v
+		}
+		properties[f.Ident.Name] = s
+		if f.Type.Ident.Name != "optional" {
+			required = append(required, f.Ident.Name)
+		}
+	}
+
+	doc := schema{
+		"$schema":              draft,
+		"title":                name,
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+
+	defs := make(schema)
+	for _, c := range r.Consts {
+		v, ok := jsonValue(c.Value)
+		if !ok {
+			continue
+		}
+		s, err := typeSchema(c.Type)
+		if err != nil {
+			return nil, fmt.Errorf("jsonschema: const %s: %w", c.Ident.Name, err)
+		}
+		s["const"] = v
+		defs[c.Ident.Name] = s
+	}
+	if len(defs) > 0 {
+		doc["$defs"] = defs
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// typeSchema returns the schema of the values of typ, walking its type
+// arguments.
+func typeSchema(typ ast.TypeExpr) (schema, error) {
+	args := make([]schema, len(typ.Args))
+	for i, arg := range typ.Args {
+		s, err := typeSchema(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = s
+	}
+
+	want := 0
+	var s schema
+	switch typ.Ident.Name {
+	case "i8", "i16", "i32", "i64":
+		s = schema{"type": "integer"}
+	case "f32", "f64":
+		s = schema{"type": "number"}
+	case "bool":
+		s = schema{"type": "boolean"}
+	case "string":
+		s = schema{"type": "string"}
+	case "binary":
+		s = schema{"type": "string", "contentEncoding": "base64"}
+	case "date":
+		s = schema{"type": "string", "format": "date-time"}
+	case "list":
+		want = 1
+		if len(args) == want {
+			s = schema{"type": "array", "items": args[0]}
+		}
+	case "set":
+		want = 1
+		if len(args) == want {
+			s = schema{"type": "array", "items": args[0], "uniqueItems": true}
+		}
+	case "map":
+		// JSON object keys are always strings
+		want = 2
+		if len(args) == want {
+			s = schema{"type": "object", "additionalProperties": args[1]}
+		}
+	case "optional":
+		want = 1
+		if len(args) == want {
+			s = schema{"anyOf": []schema{args[0], {"type": "null"}}}
+		}
+	default:
+		s = schema{"$ref": typ.Ident.Name + ".json"}
+	}
+	if len(args) != want {
+		return nil, fmt.Errorf("%s takes %d type arguments, got %d", typ.Ident.Name, want, len(args))
+	}
+	return s, nil
+}
+
+// jsonValue returns the constant value v as a JSON value, and whether it
+// has one: references to other constants and maps with non-string keys
+// don't.
+func jsonValue(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case nil, ast.NullValue:
+		return nil, true
+	case int64, float64, string, bool:
+		return v, true
+	case ast.RecordLiteral:
+		obj := make(map[string]interface{})
+		for _, f := range v.Fields {
+			fv, ok := jsonValue(f.Value)
+			if !ok {
+				return nil, false
+			}
+			obj[f.Ident.Name] = fv
+		}
+		return obj, true
+	case []interface{}:
+		arr := make([]interface{}, len(v))
+		for i, e := range v {
+			ev, ok := jsonValue(e)
+			if !ok {
+				return nil, false
+			}
+			arr[i] = ev
+		}
+		return arr, true
+	case ast.MapValue:
+		obj := make(map[string]interface{})
+		for _, e := range v.Entries {
+			k, ok := e.Key.(string)
+			if !ok {
+				return nil, false
+			}
+			ev, ok := jsonValue(e.Value)
+			if !ok {
+				return nil, false
+			}
+			obj[k] = ev
+		}
+		return obj, true
+	}
+	return nil, false
+}
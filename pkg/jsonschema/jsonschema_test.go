@@ -0,0 +1,138 @@
+package jsonschema_test
+
+import (
+	"testing"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/ast"
+	"github.com/SafetyCulture/djinni-parser/pkg/jsonschema"
+	"github.com/SafetyCulture/djinni-parser/pkg/parser"
+)
+
+func TestFromRecord(t *testing.T) {
+	t.Parallel()
+	src := `
+		person = record {
+			id: i64;
+			name: string = "anonymous";
+			scores: map<string, list<f32>>;
+			tags: set<string>;
+			avatar: optional<binary>;
+			born: date;
+			home: address;
+			const max_scores: i32 = 10;
+			const origin: address = { street = "Main St" };
+			const alias: i32 = max_scores;
+			const default_tags: set<string> = ["new"];
+		}
+	`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := jsonschema.FromRecord(f.TypeDecls[0].Body.(*ast.Record), "person")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{
+  "$defs": {
+    "default_tags": {
+      "const": [
+        "new"
+      ],
+      "items": {
+        "type": "string"
+      },
+      "type": "array",
+      "uniqueItems": true
+    },
+    "max_scores": {
+      "const": 10,
+      "type": "integer"
+    },
+    "origin": {
+      "$ref": "address.json",
+      "const": {
+        "street": "Main St"
+      }
+    }
+  },
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "additionalProperties": false,
+  "properties": {
+    "avatar": {
+      "anyOf": [
+        {
+          "contentEncoding": "base64",
+          "type": "string"
+        },
+        {
+          "type": "null"
+        }
+      ]
+    },
+    "born": {
+      "format": "date-time",
+      "type": "string"
+    },
+    "home": {
+      "$ref": "address.json"
+    },
+    "id": {
+      "type": "integer"
+    },
+    "name": {
+      "default": "anonymous",
+      "type": "string"
+    },
+    "scores": {
+      "additionalProperties": {
+        "items": {
+          "type": "number"
+        },
+        "type": "array"
+      },
+      "type": "object"
+    },
+    "tags": {
+      "items": {
+        "type": "string"
+      },
+      "type": "array",
+      "uniqueItems": true
+    }
+  },
+  "required": [
+    "id",
+    "name",
+    "scores",
+    "tags",
+    "born",
+    "home"
+  ],
+  "title": "person",
+  "type": "object"
+}`
+	if string(got) != want {
+		t.Errorf("incorrect schema:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFromRecordErrors(t *testing.T) {
+	t.Parallel()
+
+	r := &ast.Record{Fields: []ast.Field{{
+		Ident: ast.Ident{Name: "ids"},
+		Type:  ast.TypeExpr{Ident: ast.Ident{Name: "list"}},
+	}}}
+	_, err := jsonschema.FromRecord(r, "bad")
+	if err == nil || err.Error() != "jsonschema: field ids: list takes 1 type arguments, got 0" {
+		t.Errorf("incorrect error for a list without its type: %v", err)
+	}
+
+	if _, err := jsonschema.FromRecord(nil, "none"); err == nil {
+		t.Error("expected an error for a nil record")
+	}
+}
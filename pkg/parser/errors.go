@@ -1,22 +1,46 @@
 package parser
 
-import "fmt"
+import (
+	"fmt"
 
-type parseError struct {
-	msg string
+	"github.com/SafetyCulture/djinni-parser/pkg/token"
+)
+
+// Error describes a single problem found while parsing.
+// The Pos, if valid, points to the start of the offending token.
+type Error struct {
+	Pos token.Position
+	Msg string
 }
 
-func (e parseError) Error() string {
-	return e.msg
+// Error implements the error interface.
+func (e Error) Error() string {
+	if e.Pos.Filename != "" || e.Pos.IsValid() {
+		return e.Pos.String() + ": " + e.Msg
+	}
+	return e.Msg
 }
 
-type errorsList []parseError
+// ErrorList is a list of *Errors. ParseFile returns an ErrorList,
+// together with the partially parsed file, when the source has problems.
+// The parser never logs; callers decide how errors are reported.
+type ErrorList []*Error
 
-func (e errorsList) add(msg string) {
-	e = append(e, parseError{msg})
+func (e *ErrorList) add(pos token.Position, msg string) {
+	*e = append(*e, &Error{pos, msg})
+}
+
+// Unwrap returns the individual errors, so that errors.As can find
+// a *Error within the list.
+func (e ErrorList) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
 }
 
-func (e errorsList) Error() string {
+func (e ErrorList) Error() string {
 	switch len(e) {
 	case 0:
 		return "no errors"
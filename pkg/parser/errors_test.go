@@ -0,0 +1,154 @@
+package parser_test
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/parser"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// TestErrors parses each malformed file in testdata/errors and compares the
+// reported errors, one per line, to the matching .golden file. Files without
+// syntax errors are resolved, see parser.Resolve, and compared by the
+// errors that reports.
+func TestErrors(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "errors", "*.djinni"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, filename := range files {
+		filename := filename
+		t.Run(filepath.Base(filename), func(t *testing.T) {
+			f, err := parser.ParseFile(filename, nil)
+			if err == nil {
+				err = parser.Resolve(f)
+			}
+			if err == nil {
+				t.Fatal("expected errors, got none")
+			}
+
+			var b strings.Builder
+			for _, e := range err.(parser.ErrorList) {
+				b.WriteString(e.Error())
+				b.WriteByte('\n')
+			}
+			got := b.String()
+
+			golden := strings.TrimSuffix(filename, ".djinni") + ".golden"
+			if *update {
+				if err := ioutil.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("incorrect errors:\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestErrorList(t *testing.T) {
+	t.Parallel()
+	src := `
+		first = record { const c: i32 = null; }
+		second = struct { name: string; }
+		third = interface +j { static get(): third; }
+	`
+
+	f, err := parser.ParseFile("", src)
+	list, ok := err.(parser.ErrorList)
+	if !ok {
+		t.Fatalf("expected a parser.ErrorList, got %T", err)
+	}
+	if len(list) != 3 {
+		t.Errorf("incorrect number of errors; expected 3, got %d: %v", len(list), list)
+	}
+
+	if pos := list[0].Pos; pos.Line != 2 || pos.Column != 35 {
+		t.Errorf("incorrect position for the first error: %s", pos)
+	}
+
+	if f == nil {
+		t.Fatal("expected a partial file alongside the errors")
+	}
+	if len(f.TypeDecls) != 3 {
+		t.Errorf("incorrect number of decls; expected 3, got %d", len(f.TypeDecls))
+	}
+}
+
+func TestErrorsAs(t *testing.T) {
+	t.Parallel()
+
+	_, err := parser.ParseFile("bad.djinni", "my_record = record { const c: i32 = null; }")
+
+	var list parser.ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("expected errors.As to find a parser.ErrorList in %T", err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("incorrect number of errors; expected 1, got %d", len(list))
+	}
+
+	var e *parser.Error
+	if !errors.As(err, &e) {
+		t.Fatalf("expected errors.As to find a *parser.Error in %T", err)
+	}
+	if e.Pos.Filename != "bad.djinni" || e.Pos.Line != 1 || e.Msg != "null is only valid for optional constants, got i32" {
+		t.Errorf("incorrect error: %+v", e)
+	}
+}
+
+func TestMaxErrors(t *testing.T) {
+	t.Parallel()
+	src := "my_record = record {" + strings.Repeat("\n\t1", 20) + "\n}"
+
+	tests := [...]struct {
+		opts []parser.Option
+		want int
+	}{
+		{nil, 10},
+		{[]parser.Option{parser.WithMaxErrors(3)}, 3},
+		{[]parser.Option{parser.WithMaxErrors(0)}, 20},
+	}
+
+	for _, tt := range tests {
+		f, err := parser.ParseFile("", src, tt.opts...)
+		list, ok := err.(parser.ErrorList)
+		if !ok {
+			t.Fatalf("expected a parser.ErrorList, got %T", err)
+		}
+		if len(list) != tt.want {
+			t.Errorf("incorrect number of errors; expected %d, got %d", tt.want, len(list))
+		}
+		if f == nil {
+			t.Error("expected a file alongside the errors")
+		}
+	}
+}
+
+func TestAllErrors(t *testing.T) {
+	t.Parallel()
+	src := "my_record = record { 1 2 3 }"
+
+	_, err := parser.ParseFile("", src)
+	if list := err.(parser.ErrorList); len(list) != 1 {
+		t.Errorf("incorrect number of errors; expected 1, got %d: %v", len(list), list)
+	}
+
+	_, err = parser.ParseFile("", src, parser.WithAllErrors())
+	if list := err.(parser.ErrorList); len(list) != 3 {
+		t.Errorf("incorrect number of errors with WithAllErrors; expected 3, got %d: %v", len(list), list)
+	}
+}
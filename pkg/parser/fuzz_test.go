@@ -0,0 +1,63 @@
+package parser_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/parser"
+)
+
+// FuzzParseFile checks that ParseFile never panics, whatever the source;
+// run it with go test -fuzz=FuzzParseFile ./pkg/parser.
+func FuzzParseFile(f *testing.F) {
+	files, err := filepath.Glob(filepath.Join("testdata", "errors", "*.djinni"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	files = append(files, filepath.Join("..", "printer", "testdata", "example.input"))
+	for _, filename := range files {
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(src)
+	}
+	for _, src := range []string{
+		benchSrc,
+		`@import "`,
+		`@extern "`,
+		"@namespace a.1.b;",
+		"my_record = record { id: i32; } deriving (",
+		"my_enum = enum { a = ; b = all; }",
+		"my_record = record { const m: map<string, i32> = { \"a\": ",
+		"my_record = record { const c: other = { x = { y = color.",
+		"my_interface = interface +c { static f(a: list<optional<i32>>): ",
+		"my_record = record { @field=1 a: i32; } +",
+		"# only a comment",
+	} {
+		f.Add([]byte(src))
+	}
+
+	configs := [][]parser.Option{
+		nil,
+		{parser.WithStrictDjinni()},
+		{
+			parser.WithAllErrors(),
+			parser.WithComments(),
+			parser.WithNamespaces(),
+			parser.WithMultiImports(),
+			parser.WithColonDecls(),
+			parser.WithEnumDeriving(),
+			parser.WithFieldNumbers(),
+			parser.WithRawSource(),
+		},
+	}
+	f.Fuzz(func(t *testing.T, src []byte) {
+		for _, opts := range configs {
+			if file, err := parser.ParseFile("fuzz.djinni", src, opts...); file == nil {
+				t.Fatalf("expected a file, even with errors: %v", err)
+			}
+		}
+	})
+}
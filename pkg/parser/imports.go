@@ -0,0 +1,112 @@
+package parser
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/ast"
+	"github.com/SafetyCulture/djinni-parser/pkg/token"
+)
+
+// ParseFileWithImports parses the file specified by filename and, in turn,
+// every file it imports. Import paths are relative to the importing file.
+// The imported files are stored in ast.IDLFile.Imported; a file imported
+// more than once is parsed once and shared.
+//
+// Syntax errors of all files are returned together as one ErrorList,
+// along with an error for every type declared in more than one file. An
+// import cycle, or a file that couldn't be read, stops parsing and is
+// returned as is.
+func ParseFileWithImports(filename string, opts ...Option) (*ast.IDLFile, error) {
+	im := importer{
+		opts:  opts,
+		files: make(map[string]*ast.IDLFile),
+		types: make(map[string]token.Position),
+	}
+	f, err := im.parse(filename)
+	if err != nil {
+		return nil, err
+	}
+	if len(im.errors) > 0 {
+		return f, im.errors
+	}
+	return f, nil
+}
+
+// ResolveImports follows the imports of the file specified by filename,
+// like ParseFileWithImports, and reports the first import that couldn't be
+// read or that closes a cycle, e.g. "import cycle: a.djinni -> b.djinni ->
+// a.djinni". Only the imports of each file are parsed, so it is a cheap
+// check of the import graph; syntax errors are not reported.
+func ResolveImports(filename string, opts ...Option) error {
+	opts = append(opts[:len(opts):len(opts)], func(c *config) {
+		c.importsOnly = true
+	})
+	im := importer{opts: opts, files: make(map[string]*ast.IDLFile)}
+	_, err := im.parse(filename)
+	return err
+}
+
+type importer struct {
+	opts   []Option
+	files  map[string]*ast.IDLFile   // parsed files by cleaned filename
+	types  map[string]token.Position // first declaration of each type; or nil
+	stack  []string                  // files being parsed, for cycle detection
+	errors ErrorList
+}
+
+func (im *importer) parse(filename string) (*ast.IDLFile, error) {
+	filename = filepath.Clean(filename)
+	if f, ok := im.files[filename]; ok {
+		return f, nil
+	}
+	for i, name := range im.stack {
+		if name == filename {
+			cycle := append(im.stack[i:len(im.stack):len(im.stack)], filename)
+			return nil, fmt.Errorf("import cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	f, err := ParseFile(filename, nil, im.opts...)
+	if list, ok := err.(ErrorList); ok {
+		im.errors = append(im.errors, list...)
+	} else if err != nil {
+		return nil, err
+	}
+
+	im.stack = append(im.stack, filename)
+	for _, path := range f.Imports {
+		imp, err := im.parse(filepath.Join(filepath.Dir(filename), path))
+		if err != nil {
+			return nil, err
+		}
+		f.Imported = append(f.Imported, imp)
+	}
+	im.stack = im.stack[:len(im.stack)-1]
+
+	im.declare(filename, f)
+	im.files[filename] = f
+	return f, nil
+}
+
+// declare records the type declarations of the file, and reports an error
+// for each type that was already declared in another file. Duplicates
+// within the file are reported by the parser itself.
+func (im *importer) declare(filename string, f *ast.IDLFile) {
+	if im.types == nil {
+		return
+	}
+	for _, decl := range f.TypeDecls {
+		name := decl.Ident.Name
+		pos := decl.Ident.Pos.Position(filename)
+		prev, ok := im.types[name]
+		if !ok {
+			im.types[name] = pos
+			continue
+		}
+		if prev.Filename != filename {
+			im.errors.add(pos, fmt.Sprintf("duplicate type %s, also declared at %s", name, prev))
+		}
+	}
+}
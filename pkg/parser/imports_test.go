@@ -0,0 +1,121 @@
+package parser_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/parser"
+)
+
+// writeFiles writes the sources keyed by slash-separated paths below dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, src := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestParseFileWithImports(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.djinni":    "@import \"types/a.djinni\"\n@import \"common.djinni\"\nmain = record { a: a; }",
+		"types/a.djinni": "@import \"../common.djinni\"\na = record { c: common; }",
+		"common.djinni":  "common = enum { first; }",
+	})
+
+	f, err := parser.ParseFileWithImports(filepath.Join(dir, "main.djinni"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, d := range f.AllTypeDecls() {
+		names = append(names, d.Ident.Name)
+	}
+	if diff := cmp.Diff([]string{"main", "a", "common"}, names); diff != "" {
+		t.Errorf("incorrect decls: %s", diff)
+	}
+
+	if len(f.Imported) != 2 {
+		t.Fatalf("incorrect number of imported files; expected 2, got %d", len(f.Imported))
+	}
+	if f.Imported[0].Imported[0] != f.Imported[1] {
+		t.Error("expected common.djinni to be parsed once and shared")
+	}
+}
+
+func TestImportCycle(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.djinni": "@import \"b.djinni\"\na = record {}",
+		"b.djinni": "@import \"a.djinni\"\nb = record {}",
+	})
+
+	a, b := filepath.Join(dir, "a.djinni"), filepath.Join(dir, "b.djinni")
+	_, err := parser.ParseFileWithImports(a)
+	if want := "import cycle: " + a + " -> " + b + " -> " + a; err == nil || err.Error() != want {
+		t.Errorf("incorrect error: expected %q, got %v", want, err)
+	}
+}
+
+func TestDuplicateTypes(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.djinni":   "@import \"other.djinni\"\nmain = record {}\nshared = enum {}\n",
+		"other.djinni":  "shared = record {}\n",
+		"single.djinni": "one = record {}\ntwo = record {}\none = enum {}\n",
+	})
+
+	_, err := parser.ParseFileWithImports(filepath.Join(dir, "main.djinni"))
+	list, ok := err.(parser.ErrorList)
+	if !ok || len(list) != 1 {
+		t.Fatalf("expected one error, got %v", err)
+	}
+	want := "duplicate type shared, also declared at " + filepath.Join(dir, "other.djinni") + ":1:1"
+	if e := list[0]; e.Msg != want || e.Pos.Filename != filepath.Join(dir, "main.djinni") || e.Pos.Line != 3 {
+		t.Errorf("incorrect error: %v", e)
+	}
+
+	_, err = parser.ParseFile(filepath.Join(dir, "single.djinni"), nil)
+	if err == nil || err.Error() != filepath.Join(dir, "single.djinni")+":3:1: duplicate type one" {
+		t.Errorf("incorrect error for a duplicate in one file: %v", err)
+	}
+}
+
+func TestResolveImports(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.djinni":    "@import \"b.djinni\"\na = record {}",
+		"b.djinni":    "@import \"c.djinni\"\nb = record {}",
+		"c.djinni":    "@import \"a.djinni\"\nc = struct {}",
+		"ok.djinni":   "@import \"leaf.djinni\"\nok = record { leaf: leaf; }",
+		"leaf.djinni": "leaf = syntax error",
+	})
+
+	if err := parser.ResolveImports(filepath.Join(dir, "ok.djinni")); err != nil {
+		t.Errorf("expected no error for an acyclic graph, got %v", err)
+	}
+
+	a, b, c := filepath.Join(dir, "a.djinni"), filepath.Join(dir, "b.djinni"), filepath.Join(dir, "c.djinni")
+	err := parser.ResolveImports(b)
+	if want := "import cycle: " + b + " -> " + c + " -> " + a + " -> " + b; err == nil || err.Error() != want {
+		t.Errorf("incorrect error: expected %q, got %v", want, err)
+	}
+
+	if err := parser.ResolveImports(filepath.Join(dir, "missing.djinni")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
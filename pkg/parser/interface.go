@@ -3,10 +3,16 @@ package parser
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
+	"io/fs"
 	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/SafetyCulture/djinni-parser/pkg/ast"
+	"github.com/SafetyCulture/djinni-parser/pkg/token"
 )
 
 func readSource(filename string, src interface{}) ([]byte, error) {
@@ -21,21 +27,353 @@ func readSource(filename string, src interface{}) ([]byte, error) {
 				return s.Bytes(), nil
 			}
 		case io.Reader:
-			return ioutil.ReadAll(s)
+			b, err := ioutil.ReadAll(s)
+			if err != nil {
+				return nil, fmt.Errorf("reading source: %w", err)
+			}
+			return b, nil
 		}
 		return nil, errors.New("invalid source")
 	}
 	return ioutil.ReadFile(filename)
 }
 
-func ParseFile(filename string, src interface{}) (*ast.IDLFile, error) {
+// An Option configures optional parser behaviour.
+type Option func(*config)
+
+type config struct {
+	identPolicy  IdentPolicy
+	multiImports bool
+	colonDecls   bool
+	enumDeriving bool
+	fieldNumbers bool
+	maxErrors    int
+	allErrors    bool
+	comments     bool
+	namespaces   bool
+	recursive    bool
+	preprocess   func(src []byte) ([]byte, error)
+	importsOnly  bool // stop parsing after the imports, see ResolveImports
+	warn         func(pos token.Position, msg string)
+	rawSource    bool
+	strict       bool
+}
+
+// IdentPolicy restricts the identifiers accepted by the parser.
+// A nil expression accepts every identifier.
+type IdentPolicy struct {
+	TypeName   *regexp.Regexp // names of type declarations
+	MemberName *regexp.Regexp // names of fields, consts, enum options and methods
+}
+
+// WithIdentPolicy reports an error for every identifier that doesn't
+// match the policy.
+func WithIdentPolicy(policy IdentPolicy) Option {
+	return func(c *config) {
+		c.identPolicy = policy
+	}
+}
+
+// WithMultiImports allows a single @import to list several paths,
+// e.g. `@import "a.djinni" "b.djinni"`. This is not standard Djinni.
+func WithMultiImports() Option {
+	return func(c *config) {
+		c.multiImports = true
+	}
+}
+
+// WithColonDecls accepts ':' in place of '=' in type declarations,
+// e.g. `my_record : record {}`. This is not standard Djinni.
+func WithColonDecls() Option {
+	return func(c *config) {
+		c.colonDecls = true
+	}
+}
+
+// WithEnumDeriving accepts a deriving clause on enums and flags, e.g.
+// `my_enum = enum deriving (ord) {}`. Only eq and ord may be derived.
+// This is not standard Djinni.
+func WithEnumDeriving() Option {
+	return func(c *config) {
+		c.enumDeriving = true
+	}
+}
+
+// WithFieldNumbers assigns a 1-based number to every record field. A field
+// annotated with @field=N gets number N, which must be positive and unique
+// within the record. The remaining fields are numbered in declaration order,
+// skipping the numbers that were given explicitly.
+func WithFieldNumbers() Option {
+	return func(c *config) {
+		c.fieldNumbers = true
+	}
+}
+
+// WithMaxErrors stops parsing once n errors have been reported; the
+// errors found so far are returned. A value of n <= 0 removes the limit.
+// The default limit is 10.
+func WithMaxErrors(n int) Option {
+	return func(c *config) {
+		c.maxErrors = n
+	}
+}
+
+// WithAllErrors reports all errors, not just the first error on each
+// line, and removes the error limit. A later WithMaxErrors sets a new limit.
+func WithAllErrors() Option {
+	return func(c *config) {
+		c.allErrors = true
+		c.maxErrors = 0
+	}
+}
+
+// WithComments parses comments. Doc comments are attached to the nodes
+// they document, and every comment group is listed in ast.IDLFile.Comments.
+// Without this option comments are skipped.
+func WithComments() Option {
+	return func(c *config) {
+		c.comments = true
+	}
+}
+
+// WithNamespaces accepts a namespace declaration following the imports,
+// e.g. `@namespace com.example.api;`. Its dotted segments are stored in
+// ast.IDLFile.Namespace. This is not standard Djinni.
+func WithNamespaces() Option {
+	return func(c *config) {
+		c.namespaces = true
+	}
+}
+
+// WithRecursive makes ParseDir descend into subdirectories.
+func WithRecursive() Option {
+	return func(c *config) {
+		c.recursive = true
+	}
+}
+
+// WithPreprocessor passes the source through fn before scanning, e.g. to
+// expand macros. Positions refer to the source returned by fn. If fn
+// fails, its error is reported as a parse error and nothing is parsed.
+func WithPreprocessor(fn func(src []byte) ([]byte, error)) Option {
+	return func(c *config) {
+		c.preprocess = fn
+	}
+}
+
+// WithWarningHandler calls fn for every warning: a likely mistake that
+// doesn't prevent parsing, such as a repeated extension flag. Without a
+// handler, warnings are discarded.
+func WithWarningHandler(fn func(pos token.Position, msg string)) Option {
+	return func(c *config) {
+		c.warn = fn
+	}
+}
+
+// WithRawSource stores a copy of the source text of every type declaration
+// in ast.TypeDecl.RawSource, e.g. to re-emit unchanged declarations
+// verbatim.
+func WithRawSource() Option {
+	return func(c *config) {
+		c.rawSource = true
+	}
+}
+
+// WithStrictDjinni only accepts the grammar of the upstream Djinni parser.
+// It disables the WithMultiImports, WithColonDecls, WithEnumDeriving,
+// WithFieldNumbers and WithNamespaces options, rejects annotations, enum
+// option values, enum options separated by ',', map and list constants,
+// field defaults, the +w and +n flags, language flags on methods and
+// deriving before a record body, and requires interfaces to declare at
+// least one of +c, +j or +o.
+func WithStrictDjinni() Option {
+	return func(c *config) {
+		c.strict = true
+	}
+}
+
+// ParseFile parses the source of a single Djinni IDL file and returns the
+// corresponding ast.IDLFile node.
+//
+// If src != nil, ParseFile parses the source from src and the filename is
+// only used when recording position information. The type of the argument
+// for the src parameter must be string, []byte, *bytes.Buffer, or io.Reader.
+// If src == nil, ParseFile parses the file specified by filename.
+//
+// The options control the amount of source text parsed and other optional
+// parser functionality.
+//
+// If the source couldn't be read, the returned file is nil and the error
+// indicates the specific failure. If the source was read but syntax errors
+// were found, the result is a partial file and the error is an ErrorList
+// of the syntax errors in the order they were found.
+func ParseFile(filename string, src interface{}, opts ...Option) (*ast.IDLFile, error) {
+	var p parser
+	return p.parse(filename, src, opts)
+}
+
+// ParseDir calls ParseFile for all files with names ending in ".djinni"
+// in the directory specified by path and returns a map of file name -> File.
+// Subdirectories are only parsed with the WithRecursive option.
+//
+// Syntax errors don't stop ParseDir: the files are parsed regardless and
+// the errors of all files are returned together as one ErrorList. If the
+// directory couldn't be read, a nil map and the respective error are
+// returned.
+func ParseDir(path string, opts ...Option) (map[string]*ast.IDLFile, error) {
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	var filenames []string
+	err := filepath.WalkDir(path, func(filename string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if filename != path && !c.recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(filename, ".djinni") {
+			filenames = append(filenames, filename)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*ast.IDLFile, len(filenames))
+	var list ErrorList
+	for _, filename := range filenames {
+		f, err := ParseFile(filename, nil, opts...)
+		if f != nil {
+			files[filename] = f
+		}
+		if el, ok := err.(ErrorList); ok {
+			list = append(list, el...)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(list) > 0 {
+		return files, list
+	}
+	return files, nil
+}
+
+// ParseFiles calls ParseFile for each of the files and merges the results
+// into a single file. Its Imports and Externs are those of all files in
+// order, without duplicates, and its TypeDecls are those of all files; the
+// file each declaration came from is kept in ast.TypeDecl.Filename.
+// Comments and namespaces are not merged. A file named more than once,
+// compared after filepath.Clean, is only parsed the first time.
+//
+// Syntax errors don't stop ParseFiles: like ParseDir, it returns the errors
+// of all files together as one ErrorList, along with an error for every
+// type declared in more than one file. If a file couldn't be read, nil and
+// the respective error are returned.
+func ParseFiles(filenames []string, opts ...Option) (*ast.IDLFile, error) {
+	merged := &ast.IDLFile{}
+	im := importer{types: make(map[string]token.Position)}
+	imports := make(map[string]bool)
+	externs := make(map[string]bool)
+	parsed := make(map[string]bool)
+	for _, filename := range filenames {
+		clean := filepath.Clean(filename)
+		if parsed[clean] {
+			continue
+		}
+		parsed[clean] = true
+		f, err := ParseFile(filename, nil, opts...)
+		if list, ok := err.(ErrorList); ok {
+			im.errors = append(im.errors, list...)
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, path := range f.Imports {
+			if !imports[path] {
+				imports[path] = true
+				merged.Imports = append(merged.Imports, path)
+			}
+		}
+		for _, path := range f.Externs {
+			if !externs[path] {
+				externs[path] = true
+				merged.Externs = append(merged.Externs, path)
+			}
+		}
+		merged.TypeDecls = append(merged.TypeDecls, f.TypeDecls...)
+		im.declare(filename, f)
+	}
+
+	if len(im.errors) > 0 {
+		return merged, im.errors
+	}
+	return merged, nil
+}
+
+// ParseWithTrace is like ParseFile, but also returns the names of the
+// parse functions (such as parseRecord or parseEnum) that were invoked
+// for the input, in call order.
+func ParseWithTrace(filename string, src interface{}, opts ...Option) (*ast.IDLFile, []string, error) {
+	p := parser{tracing: true}
+	f, err := p.parse(filename, src, opts)
+	return f, p.traces, err
+}
+
+// Parser parses Djinni IDL files. Unlike ParseFile, a Parser can be reused
+// for many files, avoiding the setup of a new parser for each one.
+// A Parser must not be used concurrently.
+type Parser struct {
+	opts []Option
+	p    parser
+}
+
+// New returns a Parser that applies the options to every file it parses.
+func New(opts ...Option) *Parser {
+	return &Parser{opts: opts}
+}
+
+// Parse parses the source of a single Djinni IDL file. The result is
+// independent of any earlier call; only the scanner, and with it the
+// literals of the identifiers seen so far, is kept.
+func (ps *Parser) Parse(filename string, src []byte) (*ast.IDLFile, error) {
+	ps.p = parser{scanner: ps.p.scanner}
+	return ps.p.parse(filename, src, ps.opts)
+}
+
+func (p *parser) parse(filename string, src interface{}, opts []Option) (f *ast.IDLFile, err error) {
 	source, err := readSource(filename, src)
 	if err != nil {
 		return nil, err
 	}
 
-	var p parser
-	p.init(source)
+	defer func() {
+		if e := recover(); e != nil {
+			// resume same panic if it's not a bailout
+			if _, ok := e.(bailout); !ok {
+				panic(e)
+			}
+		}
+
+		// set result values
+		if f == nil {
+			// source is not a valid Djinni source file - satisfy
+			// ParseFile API and return a valid (but) empty *ast.IDLFile
+			f = &ast.IDLFile{}
+		}
+		if len(p.errors) > 0 {
+			err = p.errors
+		}
+	}()
 
-	return p.parseFile(), nil
+	p.init(filename, source, opts)
+	f = p.parseFile()
+	return
 }
@@ -4,47 +4,201 @@ package parser
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
 
 	"github.com/SafetyCulture/djinni-parser/pkg/ast"
 	"github.com/SafetyCulture/djinni-parser/pkg/scanner"
 	"github.com/SafetyCulture/djinni-parser/pkg/token"
 )
 
+// The parser panics with a bailout to stop parsing once too many
+// errors have been reported; see parser.parse.
+type bailout struct{}
+
 type parser struct {
 	scanner scanner.Scanner
+	config  config
+
+	filename string
+	src      []byte // the source, after preprocessing
 
 	tok token.Token // last read token
 	lit string      // token literal
+	pos token.Pos   // position of the last read token
+	end token.Pos   // position immediately after the previous token
+
+	comments    []*ast.CommentGroup // list of all comment groups
+	leadComment *ast.CommentGroup   // last lead comment
+	lineComment *ast.CommentGroup   // last line comment
 
-	leadComment *ast.CommentGroup // last lead comment
+	// Tracing
+	tracing bool
+	traces  []string // names of the invoked parse functions, in call order
 
-	errors errorsList
+	errors ErrorList
 }
 
-func (p *parser) init(src []byte) {
-	p.scanner.Init(src)
+func (p *parser) init(filename string, src []byte, opts []Option) {
+	p.config.maxErrors = 10
+	for _, opt := range opts {
+		opt(&p.config)
+	}
+	if p.config.strict {
+		p.config.multiImports = false
+		p.config.colonDecls = false
+		p.config.enumDeriving = false
+		p.config.fieldNumbers = false
+		p.config.namespaces = false
+	}
+	p.filename = filename
+	if p.config.preprocess != nil {
+		var err error
+		if src, err = p.config.preprocess(src); err != nil {
+			p.errors.add(token.Position{Filename: filename}, "preprocessing failed: "+err.Error())
+			src = nil
+		}
+	}
+	p.src = src
+	eh := func(pos token.Position, msg string) {
+		p.errorAt(token.Pos{Offset: pos.Offset, Line: pos.Line, Column: pos.Column}, msg)
+	}
+	p.scanner.Init(src, eh, 0)
 	p.next()
 }
 
+// Advance to the next token.
+func (p *parser) next0() {
+	for {
+		p.tok, p.lit = p.scanner.Scan()
+		if p.tok != token.COMMENT || p.config.comments {
+			break
+		}
+	}
+	pos := p.scanner.Position()
+	p.pos = token.Pos{Offset: pos.Offset, Line: pos.Line, Column: pos.Column}
+}
+
+// Consume a group of adjacent comments and return it together with
+// the line at which the last comment in the group ends. A non-comment
+// token or n empty lines terminate a comment group.
+func (p *parser) consumeCommentGroup(n int) (comments *ast.CommentGroup, endline int) {
+	var list []*ast.Comment
+	endline = p.pos.Line
+	for p.tok == token.COMMENT && p.pos.Line <= endline+n {
+		list = append(list, &ast.Comment{Pos: p.pos, Text: p.lit})
+		endline = p.pos.Line
+		p.next0()
+	}
+
+	// add comment group to the comments list
+	comments = &ast.CommentGroup{List: list}
+	p.comments = append(p.comments, comments)
+
+	return
+}
+
+// Advance to the next non-comment token. In the process, collect
+// any comment groups encountered, and remember the last lead and
+// line comments.
+//
+// A lead comment is a comment group that starts and ends in a line
+// without any other tokens and that is followed by a non-comment
+// token on the line immediately after the comment group.
+//
+// A line comment is a comment group that follows a non-comment
+// token on the same line, and that has no tokens after it on the
+// line where it ends.
 func (p *parser) next() {
 	p.leadComment = nil
-
-	p.tok, p.lit = p.scanner.Scan()
+	p.lineComment = nil
+	if p.pos.IsValid() {
+		// Tokens never span lines, so the end is on the same line.
+		n := len(p.lit)
+		if n == 0 && p.tok != token.EOF {
+			n = len(p.tok.String())
+		}
+		p.end = token.Pos{Offset: p.pos.Offset + n, Line: p.pos.Line, Column: p.pos.Column + n}
+	}
+	prev := p.pos.Line
+	p.next0()
 
 	if p.tok == token.COMMENT {
-		// TODO: Consume the comments to parse as docs for the next token
-		p.next()
+		var comment *ast.CommentGroup
+		var endline int
+
+		if p.pos.Line == prev {
+			// The comment is on the same line as the previous token;
+			// it cannot be a lead comment but may be a line comment.
+			comment, endline = p.consumeCommentGroup(0)
+			if p.pos.Line != endline || p.tok == token.EOF {
+				// The next token is on a different line, thus
+				// the last comment group is a line comment.
+				p.lineComment = comment
+			}
+		}
+
+		// consume successor comments, if any
+		endline = -1
+		for p.tok == token.COMMENT {
+			comment, endline = p.consumeCommentGroup(1)
+		}
+
+		if endline+1 == p.pos.Line {
+			// The next token is following on the line immediately after the
+			// comment group, thus the last comment group is a lead comment.
+			p.leadComment = comment
+		}
 	}
 }
 
+// trace records the invoked parse function when tracing is enabled.
+func (p *parser) trace(name string) {
+	if p.tracing {
+		p.traces = append(p.traces, name)
+	}
+}
+
+// errorf reports an error at the current token.
 func (p *parser) errorf(msg string, args ...interface{}) {
+	p.errorAt(p.pos, msg, args...)
+}
+
+func (p *parser) errorAt(pos token.Pos, msg string, args ...interface{}) {
+	// If AllErrors is not set, discard errors reported on the same line
+	// as the last recorded error.
+	if !p.config.allErrors {
+		n := len(p.errors)
+		if n > 0 && p.errors[n-1].Pos.Line == pos.Line {
+			return // discard - likely a spurious error
+		}
+	}
 
 	// Track all errors and continue parsing.
-	p.errors.add(fmt.Sprintf(msg, args...))
+	p.errors.add(pos.Position(p.filename), fmt.Sprintf(msg, args...))
 
 	// bailout if too many errors
-	if len(p.errors) > 10 {
-		// TODO: bailout
+	if p.config.maxErrors > 0 && len(p.errors) >= p.config.maxErrors {
+		panic(bailout{})
+	}
+}
+
+// warnf reports a warning at the current token.
+func (p *parser) warnf(msg string, args ...interface{}) {
+	p.warnAt(p.pos, msg, args...)
+}
+
+func (p *parser) warnAt(pos token.Pos, msg string, args ...interface{}) {
+	if p.config.warn != nil {
+		p.config.warn(pos.Position(p.filename), fmt.Sprintf(msg, args...))
+	}
+}
+
+// checkIdent reports an error if the current identifier doesn't match the
+// naming policy re. kind describes the identifier, e.g. "type".
+func (p *parser) checkIdent(re *regexp.Regexp, kind string) {
+	if p.tok == token.IDENT && re != nil && !re.MatchString(p.lit) {
+		p.errorf("%s name %q does not match %s", kind, p.lit, re)
 	}
 }
 
@@ -55,25 +209,147 @@ func (p *parser) expect(tok token.Token) {
 	p.next()
 }
 
-func (p *parser) parseImport() (i string) {
+// expectSemi is like expect(token.SEMICOLON), but leaves a '}' in place
+// so that a missing ';' before it doesn't also lose the end of the body.
+func (p *parser) expectSemi() {
+	if p.tok == token.RBRACE {
+		p.errorf("expected %q, got %q", token.SEMICOLON, p.tok)
+		return
+	}
+	p.expect(token.SEMICOLON)
+}
+
+// expectClosing is like expect(token.RBRACE), but reports a missing brace
+// at the end of the file together with the line of the opening brace.
+func (p *parser) expectClosing(lbrace token.Pos, what string) {
+	if p.tok == token.EOF {
+		p.errorf("unexpected end of file: expected '}' to close %s started at line %d", what, lbrace.Line)
+		return
+	}
+	p.expect(token.RBRACE)
+}
+
+// Imports are in the form @import STRING, or @import STRING {STRING} when
+// multiple imports are enabled.
+func (p *parser) parseImport() (imports []string) {
+	p.trace("parseImport")
+	p.next()
+	if p.tok != token.STRING {
+		p.expect(token.STRING)
+		return
+	}
+	for p.tok == token.STRING {
+		imports = append(imports, p.importPath())
+		p.next()
+		if !p.config.multiImports {
+			break
+		}
+	}
+	return
+}
+
+// Externs are in the form @extern STRING
+func (p *parser) parseExtern() (path string) {
+	p.trace("parseExtern")
 	p.next()
 	if p.tok != token.STRING {
 		p.expect(token.STRING)
 		return
 	}
+	path = p.importPath()
+	p.next()
+	return
+}
+
+// importPath returns the path of the current STRING literal. The path of
+// an unterminated string, which the scanner has reported, is the text
+// after the opening quote.
+func (p *parser) importPath() string {
+	if !terminated(p.lit) {
+		return p.lit[1:]
+	}
 	// strip the quotes
-	i = string(p.lit[1 : len(p.lit)-1])
+	return p.lit[1 : len(p.lit)-1]
+}
+
+// Namespaces are in the form @namespace IDENT {. IDENT} ;
+func (p *parser) parseNamespace() (segments []string) {
+	p.trace("parseNamespace")
 	p.next()
+	for {
+		switch p.tok {
+		case token.IDENT:
+			segments = append(segments, p.lit)
+			p.next()
+		case token.INT, token.FLOAT:
+			p.parseBadSegment(p.pos, "")
+		default:
+			p.errorf("expected namespace segment, got %q", p.tok)
+		}
+		if p.tok == token.FLOAT && p.lit[0] == '.' {
+			// the scanner reads a period followed by a digit as a float
+			pos := token.Pos{Offset: p.pos.Offset + 1, Line: p.pos.Line, Column: p.pos.Column + 1}
+			seg := p.lit[1:]
+			p.next()
+			p.parseBadSegment(pos, seg)
+		}
+		if p.tok != token.PERIOD {
+			break
+		}
+		p.next()
+	}
+	p.expect(token.SEMICOLON)
 	return
 }
 
-func (p *parser) parseLangExt() ast.Ext {
-	ext := ast.Ext{}
-	if !p.tok.IsLangExt() {
+// parseBadSegment reports a namespace segment that starts with a digit at
+// pos. The scanner splits such a segment, e.g. 1abc into 1 and abc, so the
+// tokens up to the next '.' or ';' are appended to the prefix seg.
+func (p *parser) parseBadSegment(pos token.Pos, seg string) {
+	for p.tok != token.PERIOD && p.tok != token.SEMICOLON && p.tok != token.EOF {
+		seg += p.lit
+		p.next()
+	}
+	p.errorAt(pos, "namespace segment %q must not start with a digit", seg)
+}
+
+// Annotations are only permitted between the '=' and the type keyword,
+// e.g. `my_record = @json record +c {}`, and before record fields. As the
+// extension list follows the keyword, an annotation never appears after it.
+func (p *parser) parseAnnotations() (annotations []ast.Annotation) {
+	p.trace("parseAnnotations")
+	for p.tok == token.ANNOTATION {
+		if p.config.strict {
+			p.errorf("annotation %s is not standard Djinni", p.lit)
+		}
+		// strip the '@'
+		a := ast.Annotation{Pos: p.pos, Name: p.lit[1:]}
 		p.next()
-		return ext
+		if p.tok == token.ASSIGN {
+			p.next()
+			switch p.tok {
+			case token.INT, token.FLOAT, token.STRING, token.IDENT:
+				a.Value = p.lit
+				p.next()
+			default:
+				p.errorf("expected annotation value, got %q", p.tok)
+			}
+		}
+		a.End = p.end
+		annotations = append(annotations, a)
 	}
+	return
+}
+
+func (p *parser) parseLangExt() ast.Ext {
+	p.trace("parseLangExt")
+	ext := ast.Ext{}
+	seen := make(map[token.Token]bool)
 	for p.tok.IsLangExt() {
+		if seen[p.tok] {
+			p.warnf("duplicate extension flag %s", p.tok)
+		}
+		seen[p.tok] = true
 		switch p.tok {
 		case token.CPP:
 			ext.CPP = true
@@ -81,79 +357,699 @@ func (p *parser) parseLangExt() ast.Ext {
 			ext.ObjC = true
 		case token.JAVA:
 			ext.Java = true
+		case token.WASM, token.NODE:
+			if p.config.strict {
+				p.errorf("extension flag %s is not standard Djinni", p.tok)
+			}
+			ext.Wasm = ext.Wasm || p.tok == token.WASM
+			ext.Node = ext.Node || p.tok == token.NODE
 		}
 		p.next()
 	}
+	for p.tok == token.ANNOTATION {
+		p.errorf("annotation %s must precede the type keyword", p.lit)
+		p.next()
+	}
 	return ext
 }
 
 func (p *parser) parseRecord() *ast.Record {
+	p.trace("parseRecord")
+	pos := p.pos
 	p.next()
-	ext := p.parseLangExt()
+	r := &ast.Record{Pos: pos, Ext: p.parseLangExt()}
+	var deriving token.Pos
+	if p.tok == token.DERIVING {
+		deriving = p.pos
+		if p.config.strict {
+			p.errorf("deriving must follow the closing '}' of the record")
+		}
+		r.Deriving = p.parseDeriving()
+	}
+	lbrace := p.pos
 	p.expect(token.LBRACE)
 
-	// TODO: handle all the record fields
+	seen := make(map[string]bool)
 	for p.tok != token.RBRACE && p.tok != token.EOF {
+		switch p.tok {
+		case token.CONST:
+			c := p.parseRecordConst()
+			p.declare(seen, "const", c.Ident)
+			r.Consts = append(r.Consts, c)
+		case token.IDENT, token.ANNOTATION:
+			f := p.parseRecordField()
+			p.declare(seen, "field", f.Ident)
+			r.Fields = append(r.Fields, f)
+		default:
+			p.errorf("expected field or const, got %q", p.tok)
+			p.next()
+		}
+	}
+
+	p.expectClosing(lbrace, "record")
+
+	if p.config.fieldNumbers {
+		p.numberFields(r.Fields)
+	}
+
+	if p.tok == token.DERIVING {
+		if deriving.IsValid() {
+			p.errorf("duplicate deriving clause, also at line %d", deriving.Line)
+		}
+		// merge the traits so that neither clause is lost
+		d := p.parseDeriving()
+		r.Deriving.Eq = r.Deriving.Eq || d.Eq
+		r.Deriving.Ord = r.Deriving.Ord || d.Ord
+		r.Deriving.Parcelable = r.Deriving.Parcelable || d.Parcelable
+	}
+	r.End = p.end
+
+	return r
+}
+
+// declare records the name of a member or type in seen, and reports an
+// error at the identifier if one of the same name was already declared.
+// The placeholder "_" of a missing identifier is never a duplicate.
+func (p *parser) declare(seen map[string]bool, kind string, ident ast.Ident) {
+	if ident.Name == "_" {
+		return
+	}
+	if seen[ident.Name] {
+		p.errorAt(ident.Pos, "duplicate %s %s", kind, ident.Name)
+		return
+	}
+	seen[ident.Name] = true
+}
+
+// numberFields assigns the field numbers of a record: explicit @field=N
+// annotations first, then the remaining fields in declaration order.
+func (p *parser) numberFields(fields []ast.Field) {
+	used := make(map[int]bool)
+	for i := range fields {
+		for _, a := range fields[i].Annotations {
+			if a.Name != "field" {
+				continue
+			}
+			n, err := strconv.Atoi(a.Value)
+			if err != nil || n <= 0 {
+				p.errorAt(a.Pos, "field number of %s must be a positive integer, got %q", fields[i].Ident.Name, a.Value)
+				continue
+			}
+			if used[n] {
+				p.errorAt(a.Pos, "duplicate field number %d for %s", n, fields[i].Ident.Name)
+				continue
+			}
+			used[n] = true
+			fields[i].Number = n
+		}
+	}
+	next := 1
+	for i := range fields {
+		if fields[i].Number != 0 {
+			continue
+		}
+		for used[next] {
+			next++
+		}
+		fields[i].Number = next
+		used[next] = true
+	}
+}
+
+// Deriving clauses are in the form deriving ( [TRAIT {, TRAIT}] )
+func (p *parser) parseDeriving() (d ast.Deriving) {
+	p.trace("parseDeriving")
+	p.next()
+	p.expect(token.LPAREN)
+	for p.tok != token.RPAREN && p.tok != token.EOF {
+		switch p.tok {
+		case token.EQUALITY:
+			d.Eq = true
+		case token.ORDERING:
+			d.Ord = true
+		case token.PARCELABLE:
+			d.Parcelable = true
+		default:
+			p.errorf("unknown deriving trait %q", p.lit)
+		}
+		p.next()
+		if p.tok != token.COMMA {
+			break
+		}
 		p.next()
 	}
+	p.expect(token.RPAREN)
+	return
+}
 
-	p.expect(token.RBRACE)
+// Fields are in the form {ANNOTATION} IDENT : TYPE [= VALUE] ;
+func (p *parser) parseRecordField() ast.Field {
+	p.trace("parseRecordField")
+	doc := p.leadComment
+	annotations := p.parseAnnotations()
+	p.checkIdent(p.config.identPolicy.MemberName, "member")
+	f := ast.Field{Doc: doc, Annotations: annotations, Ident: p.parseIdent()}
+	f.Pos = f.Ident.Pos
+	p.expect(token.COLON)
+	f.Type = p.parseRecordType()
+	if p.tok == token.ASSIGN {
+		if p.config.strict {
+			p.errorf("field defaults are not standard Djinni")
+		}
+		p.next()
+		pos := p.pos
+		f.Default = p.parseConstValue(&f.Type)
+		f.DefaultRaw = p.rawValue(pos)
+		if _, ok := f.Default.(ast.NullValue); ok && f.Type.Ident.Name != token.OPTIONAL.String() {
+			p.errorAt(pos, "null is only valid for optional fields, got %s", f.Type)
+		}
+	}
+	p.expectSemi()
+	f.End = p.end
+	f.Comment = p.lineComment
+	return f
+}
+
+// Consts are in the form const IDENT : TYPE = VALUE ;
+func (p *parser) parseRecordConst() ast.Const {
+	p.trace("parseRecordConst")
+	doc := p.leadComment
+	pos := p.pos
+	p.next()
+	p.checkIdent(p.config.identPolicy.MemberName, "member")
+	c := p.parseConst(p.parseIdent())
+	c.Pos = pos
+	c.Doc = doc
+	return c
+}
 
-	return &ast.Record{
-		Ext: ext,
+func (p *parser) parseConst(ident ast.Ident) ast.Const {
+	p.trace("parseConst")
+	c := ast.Const{Ident: ident}
+	p.expect(token.COLON)
+	c.Type = p.parseRecordType()
+	p.expect(token.ASSIGN)
+	pos := p.pos
+	c.Value = p.parseConstValue(&c.Type)
+	c.Raw = p.rawValue(pos)
+	if _, ok := c.Value.(ast.NullValue); ok && c.Type.Ident.Name != token.OPTIONAL.String() {
+		p.errorAt(pos, "null is only valid for optional constants, got %s", c.Type)
 	}
+	p.expectSemi()
+	c.End = p.end
+	return c
 }
 
-func (p *parser) parseInterface() *ast.Interface {
+// rawValue returns the source text of the value that started at pos and
+// was just parsed.
+func (p *parser) rawValue(pos token.Pos) string {
+	if pos.Offset > p.end.Offset {
+		return ""
+	}
+	return string(p.src[pos.Offset:p.end.Offset])
+}
+
+// parseNumber parses the current INT or FLOAT literal, prefixed by sign.
+func (p *parser) parseNumber(sign string) interface{} {
+	lit := sign + p.lit
+	var v interface{}
+	var err error
+	if p.tok == token.INT {
+		v, err = scanner.IntValue(lit)
+		if err != nil {
+			p.errorf("invalid integer %s", lit)
+		}
+	} else {
+		v, err = strconv.ParseFloat(lit, 64)
+		if err != nil {
+			p.errorf("invalid float %s", lit)
+		}
+	}
 	p.next()
-	ext := p.parseLangExt()
-	p.expect(token.LBRACE)
+	return v
+}
 
-	// TODO: handle all the interface methods
-	for p.tok != token.RBRACE && p.tok != token.EOF {
+// isNumeric reports whether t, or the type it makes optional, is one of the
+// integer or floating point primitives. The placeholder of a missing type
+// counts as numeric, as its error has already been reported.
+func isNumeric(t ast.TypeExpr) bool {
+	if t.Ident.Name == token.OPTIONAL.String() && len(t.Args) == 1 {
+		t = t.Args[0]
+	}
+	switch t.Ident.Name {
+	case "i8", "i16", "i32", "i64", "f32", "f64", "_":
+		return true
+	}
+	return false
+}
+
+// parseConstValue parses a value of type typ. If typ is nil, the type is
+// unknown, e.g. for the fields of a record literal.
+func (p *parser) parseConstValue(typ *ast.TypeExpr) interface{} {
+	p.trace("parseConstValue")
+	switch p.tok {
+	case token.INT, token.FLOAT:
+		return p.parseNumber("")
+	case token.MINUS:
+		pos := p.pos
+		p.next()
+		if p.tok != token.INT && p.tok != token.FLOAT {
+			p.errorf("expected number after '-', got %q", p.tok)
+			// skip the operand, but not the end of the value
+			switch p.tok {
+			case token.SEMICOLON, token.COMMA, token.RBRACE, token.RBRACK, token.EOF:
+			default:
+				p.next()
+			}
+			return nil
+		}
+		if typ != nil && !isNumeric(*typ) {
+			p.errorAt(pos, "'-' is only valid for numeric constants, got %s", *typ)
+		}
+		return p.parseNumber("-")
+	case token.STRING:
+		v, err := strconv.Unquote(p.lit)
+		if err != nil && terminated(p.lit) {
+			p.errorf("invalid string %s", p.lit)
+		}
 		p.next()
+		return v
+	case token.IDENT:
+		switch p.lit {
+		case "null":
+			p.next()
+			return ast.NullValue{}
+		case "true", "false":
+			v := p.lit == "true"
+			p.next()
+			return v
+		}
+		return p.parseRef()
+	case token.LBRACE:
+		if typ != nil && typ.Ident.Name == token.MAP.String() && len(typ.Args) == 2 {
+			if p.config.strict {
+				p.errorf("map constants are not standard Djinni")
+			}
+			return p.parseMapLiteral(typ.Args[0], typ.Args[1])
+		}
+		return p.parseRecordLiteral()
+	case token.LBRACK:
+		if p.config.strict {
+			p.errorf("list constants are not standard Djinni")
+		}
+		var elem *ast.TypeExpr
+		if typ != nil && (typ.Ident.Name == token.LIST.String() || typ.Ident.Name == token.SET.String()) && len(typ.Args) == 1 {
+			elem = &typ.Args[0]
+		}
+		return p.parseListLiteral(elem)
 	}
+	p.errorf("expected constant value, got %q", p.tok)
+	p.next()
+	return nil
+}
 
-	p.expect(token.RBRACE)
+// terminated reports whether the STRING literal lit ends with its closing
+// quote. The scanner reports unterminated strings itself.
+func terminated(lit string) bool {
+	if len(lit) < 2 || lit[len(lit)-1] != '"' {
+		return false
+	}
+	// the closing quote must not be escaped
+	n := 0
+	for i := len(lit) - 2; i > 0 && lit[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 0
+}
 
-	return &ast.Interface{
-		Ext: ext,
+// References are in the form IDENT, for another constant, or IDENT . IDENT
+// for an enum option.
+func (p *parser) parseRef() ast.Ref {
+	p.trace("parseRef")
+	ref := ast.Ref{Ident: p.parseIdent()}
+	if p.tok == token.PERIOD {
+		p.next()
+		scope := ref.Ident
+		ref.Scope = &scope
+		ref.Ident = p.parseIdent()
 	}
+	return ref
 }
 
-func (p *parser) parseEnum(isFlags bool) *ast.Enum {
+// Record literals are in the form { [IDENT = VALUE {, IDENT = VALUE}] [,] }
+func (p *parser) parseRecordLiteral() ast.RecordLiteral {
+	p.trace("parseRecordLiteral")
+	var lit ast.RecordLiteral
+	lbrace := p.pos
 	p.expect(token.LBRACE)
+	for p.tok != token.RBRACE && p.tok != token.EOF {
+		f := ast.FieldValue{Ident: p.parseIdent()}
+		p.expect(token.ASSIGN)
+		if p.tok == token.COMMA || p.tok == token.RBRACE {
+			p.errorf("missing value for field %s", f.Ident.Name)
+		} else {
+			f.Value = p.parseConstValue(nil)
+		}
+		lit.Fields = append(lit.Fields, f)
+		if p.tok != token.COMMA {
+			break
+		}
+		p.next()
+	}
+	p.expectClosing(lbrace, "record literal")
+	return lit
+}
 
-	// TODO: handle all options
+// Map literals are in the form { [VALUE : VALUE {, VALUE : VALUE}] [,] }
+func (p *parser) parseMapLiteral(key, value ast.TypeExpr) ast.MapValue {
+	p.trace("parseMapLiteral")
+	var lit ast.MapValue
+	seen := make(map[interface{}]bool)
+	lbrace := p.pos
+	p.expect(token.LBRACE)
 	for p.tok != token.RBRACE && p.tok != token.EOF {
+		pos := p.pos
+		e := ast.MapEntry{Key: p.parseConstValue(&key)}
+		switch k := e.Key.(type) {
+		case int64, float64, string, bool:
+			if seen[k] {
+				p.errorAt(pos, "duplicate key %#v in map literal", k)
+			}
+			seen[k] = true
+		}
+		p.expect(token.COLON)
+		e.Value = p.parseConstValue(&value)
+		lit.Entries = append(lit.Entries, e)
+		if p.tok != token.COMMA {
+			break
+		}
 		p.next()
 	}
+	p.expectClosing(lbrace, "map literal")
+	return lit
+}
 
-	p.expect(token.RBRACE)
+// List literals are in the form [ [VALUE {, VALUE}] [,] ]. They are used
+// for the values of both lists and sets.
+func (p *parser) parseListLiteral(elem *ast.TypeExpr) []interface{} {
+	p.trace("parseListLiteral")
+	list := []interface{}{}
+	p.expect(token.LBRACK)
+	for p.tok != token.RBRACK && p.tok != token.EOF {
+		list = append(list, p.parseConstValue(elem))
+		if p.tok != token.COMMA {
+			break
+		}
+		p.next()
+	}
+	p.expect(token.RBRACK)
+	return list
+}
+
+// Types are either a plain IDENT, a decorated type such as list<TYPE>
+// or a map<TYPE, TYPE>.
+func (p *parser) parseRecordType() ast.TypeExpr {
+	p.trace("parseRecordType")
+	switch p.tok {
+	case token.MAP:
+		return p.parseMap()
+	case token.LIST, token.SET, token.OPTIONAL:
+		return p.parseDecorated()
+	case token.IDENT:
+		// unknown types are reported by Resolve
+		ident := p.parseIdent()
+		return ast.TypeExpr{Pos: ident.Pos, End: ident.End, Ident: ident}
+	}
+	p.errorf("expected type, got %q", p.tok)
+	ident := ast.Ident{Pos: p.pos, Name: "_"}
+	p.next()
+	ident.End = p.end
+	return ast.TypeExpr{Pos: ident.Pos, End: ident.End, Ident: ident}
+}
 
-	return &ast.Enum{
-		Flags: isFlags,
+// parseKeywordIdent returns the current keyword, e.g. list, as an identifier.
+func (p *parser) parseKeywordIdent() ast.Ident {
+	ident := ast.Ident{Pos: p.pos, Name: p.tok.String()}
+	p.next()
+	ident.End = p.end
+	return ident
+}
+
+func (p *parser) parseDecorated() ast.TypeExpr {
+	p.trace("parseDecorated")
+	pos := p.pos
+	t := ast.TypeExpr{Pos: pos, Ident: p.parseKeywordIdent()}
+	p.expect(token.LANGLE)
+	t.Args = []ast.TypeExpr{p.parseRecordType()}
+	p.expect(token.RANGLE)
+	t.End = p.end
+	if t.Ident.Name == token.OPTIONAL.String() && t.Args[0].Ident.Name == token.OPTIONAL.String() {
+		p.warnAt(pos, "redundant nested optional in %s", t)
 	}
+	return t
 }
 
-func (p *parser) parseIdent() ast.Ident {
-	name := "_"
-	if p.tok == token.IDENT {
-		name = p.lit
+func (p *parser) parseMap() ast.TypeExpr {
+	p.trace("parseMap")
+	pos := p.pos
+	t := ast.TypeExpr{Pos: pos, Ident: p.parseKeywordIdent()}
+	p.expect(token.LANGLE)
+	key := p.parseRecordType()
+	p.expect(token.COMMA)
+	value := p.parseRecordType()
+	p.expect(token.RANGLE)
+	t.Args = []ast.TypeExpr{key, value}
+	t.End = p.end
+	return t
+}
+
+func (p *parser) parseInterface() *ast.Interface {
+	p.trace("parseInterface")
+	pos := p.pos
+	p.next()
+	i := &ast.Interface{Pos: pos, Ext: p.parseLangExt()}
+	if p.config.strict && !i.Ext.CPP && !i.Ext.ObjC && !i.Ext.Java {
+		p.errorAt(pos, "interface must declare at least one language: +c, +j or +o")
+	}
+	lbrace := p.pos
+	p.expect(token.LBRACE)
+
+	consts := make(map[string]bool)
+	for p.tok != token.RBRACE && p.tok != token.EOF {
+		doc := p.leadComment
+		pos := p.pos
+		switch p.tok {
+		case token.STATIC:
+			p.next()
+			m := p.parseMethod()
+			m.Pos = pos
+			m.Doc = doc
+			if !i.Ext.CPP {
+				p.errorAt(pos, "static method %s is only allowed in +c interfaces", m.Ident.Name)
+			}
+			m.Static = true
+			i.Methods = append(i.Methods, m)
+		case token.CONST:
+			// const either marks a const method or declares a constant;
+			// a method is told apart by the '(' following the identifier.
+			p.next()
+			p.checkIdent(p.config.identPolicy.MemberName, "member")
+			ident := p.parseIdent()
+			if p.tok == token.LPAREN {
+				m := p.parseMethodSignature(ident)
+				m.Pos = pos
+				m.Doc = doc
+				m.Const = true
+				i.Methods = append(i.Methods, m)
+			} else {
+				c := p.parseConst(ident)
+				c.Pos = pos
+				c.Doc = doc
+				p.declare(consts, "const", c.Ident)
+				i.Consts = append(i.Consts, c)
+			}
+		case token.IDENT:
+			m := p.parseMethod()
+			m.Doc = doc
+			i.Methods = append(i.Methods, m)
+		default:
+			p.errorf("expected method, got %q", p.tok)
+			p.next()
+		}
+	}
+
+	p.expectClosing(lbrace, "interface")
+	i.End = p.end
+
+	return i
+}
+
+// Methods are in the form [static|const] IDENT ( [PARAM {, PARAM}] ) [: TYPE] [EXT] ;
+func (p *parser) parseMethod() ast.Method {
+	p.trace("parseMethod")
+	p.checkIdent(p.config.identPolicy.MemberName, "member")
+	return p.parseMethodSignature(p.parseIdent())
+}
+
+func (p *parser) parseMethodSignature(ident ast.Ident) ast.Method {
+	p.trace("parseMethodSignature")
+	m := ast.Method{Pos: ident.Pos, Ident: ident}
+
+	p.expect(token.LPAREN)
+	for p.tok != token.RPAREN && p.tok != token.EOF {
+		m.Params = append(m.Params, p.parseParam())
+		if p.tok != token.COMMA {
+			break
+		}
 		p.next()
+	}
+	p.expect(token.RPAREN)
+
+	if p.tok == token.COLON {
+		p.next()
+		ret := p.parseRecordType()
+		m.Return = &ret
+	}
+	if p.config.strict && p.tok.IsLangExt() {
+		p.errorf("method language flags are not standard Djinni")
+	}
+	m.Ext = p.parseLangExt()
+	p.expectSemi()
+	m.End = p.end
+	return m
+}
+
+// Params are in the form IDENT : TYPE
+func (p *parser) parseParam() ast.Field {
+	p.trace("parseParam")
+	f := ast.Field{Ident: p.parseIdent()}
+	f.Pos = f.Ident.Pos
+	p.expect(token.COLON)
+	f.Type = p.parseRecordType()
+	f.End = p.end
+	return f
+}
+
+func (p *parser) parseEnum(isFlags bool) *ast.Enum {
+	p.trace("parseEnum")
+	pos := p.pos
+	p.next()
+	e := &ast.Enum{Pos: pos, Flags: isFlags}
+	if p.tok == token.DERIVING {
+		pos := p.pos
+		if !p.config.enumDeriving {
+			p.errorf("deriving is not supported on enums")
+		}
+		e.Deriving = p.parseDeriving()
+		if e.Deriving.Parcelable {
+			p.errorAt(pos, "enums cannot derive parcelable")
+		}
+	}
+	lbrace := p.pos
+	p.expect(token.LBRACE)
+
+	seen := make(map[string]bool)
+	for p.tok != token.RBRACE && p.tok != token.EOF {
+		switch p.tok {
+		case token.IDENT:
+			o := p.parseEnumOption(isFlags)
+			p.declare(seen, "option", o.Ident)
+			e.Options = append(e.Options, o)
+		default:
+			p.errorf("expected enum option, got %q", p.tok)
+			p.next()
+		}
+	}
+
+	if isFlags {
+		p.expectClosing(lbrace, "flags")
+		p.checkFlagOptions(pos, e.Options)
 	} else {
-		p.expect(token.IDENT)
+		p.expectClosing(lbrace, "enum")
 	}
+	e.End = p.end
 
-	return ast.Ident{Name: name}
+	return e
 }
 
-func (p *parser) parseTypeDef() ast.TypeDef {
-	if !p.tok.IsTypeDef() {
-		p.errorf("expected one of %v, got %q", token.TypeDefTokens(), p.tok)
+// checkFlagOptions warns about flags whose only options are all and none,
+// which have no flags to combine.
+func (p *parser) checkFlagOptions(pos token.Pos, options []ast.EnumOption) {
+	if len(options) == 0 {
+		return
+	}
+	for _, o := range options {
+		if !o.IsAll && !o.IsNone {
+			return
+		}
+	}
+	p.warnAt(pos, "flags has no options besides all and none")
+}
+
+// Enum options are in the form IDENT [= INT] ; and flags options may
+// also be in the form IDENT = all ; or IDENT = none ; Unless strict, the
+// options may also be separated by ',' and the last separator is optional.
+func (p *parser) parseEnumOption(isFlags bool) ast.EnumOption {
+	p.trace("parseEnumOption")
+	doc := p.leadComment
+	p.checkIdent(p.config.identPolicy.MemberName, "member")
+	o := ast.EnumOption{Doc: doc, Ident: p.parseIdent()}
+	o.Pos = o.Ident.Pos
+	if p.tok == token.ASSIGN {
+		pos := p.pos
 		p.next()
+		switch {
+		case p.tok == token.INT:
+			if p.config.strict {
+				p.errorAt(pos, "enum option values are not standard Djinni")
+			}
+			v, err := scanner.IntValue(p.lit)
+			if err != nil {
+				p.errorf("invalid value %s for option %s", p.lit, o.Ident.Name)
+			}
+			n := int(v)
+			o.Value = &n
+			o.RawValue = p.lit
+			p.next()
+		case p.tok == token.IDENT && (p.lit == "all" || p.lit == "none"):
+			if !isFlags {
+				p.errorf("%s is only valid for flags options", p.lit)
+			}
+			o.IsAll = p.lit == "all"
+			o.IsNone = p.lit == "none"
+			p.next()
+		default:
+			p.errorf("expected value for option %s, got %q", o.Ident.Name, p.tok)
+		}
+	}
+	switch {
+	case p.config.strict:
+		p.expectSemi()
+	case p.tok == token.SEMICOLON || p.tok == token.COMMA:
+		p.next()
+	case p.tok != token.RBRACE:
+		p.expect(token.SEMICOLON)
 	}
+	o.End = p.end
+	o.Comment = p.lineComment
+	return o
+}
 
+func (p *parser) parseIdent() ast.Ident {
+	p.trace("parseIdent")
+	pos := p.pos
+	if p.tok != token.IDENT {
+		p.expect(token.IDENT)
+		return ast.Ident{Pos: pos, End: pos, Name: "_"}
+	}
+
+	name := p.lit
+	p.next()
+	return ast.Ident{Pos: pos, End: p.end, Name: name}
+}
+
+func (p *parser) parseTypeDef() ast.TypeDef {
+	p.trace("parseTypeDef")
 	switch p.tok {
 	case token.RECORD:
 		return p.parseRecord()
@@ -164,34 +1060,81 @@ func (p *parser) parseTypeDef() ast.TypeDef {
 	case token.FLAGS:
 		return p.parseEnum(true)
 	default:
-		return nil
+		p.errorf("expected one of %v, got %q", token.TypeDefTokens(), p.tok)
+		pos := p.pos
+		p.skipDef()
+		return &ast.BadDef{Pos: pos, End: p.end}
 	}
 }
 
-// All decls should be in the form IDENT = KEYWORD [EXT] { }
+// skipDef advances past the body of a bad type definition, so parsing can
+// resume at the next declaration.
+func (p *parser) skipDef() {
+	for p.tok != token.RBRACE && p.tok != token.EOF {
+		p.next()
+	}
+	p.next()
+}
+
+// All decls should be in the form IDENT = [ANNOTATION] KEYWORD [EXT] { }
 func (p *parser) parseDecl() (decl ast.TypeDecl) {
+	p.trace("parseDecl")
+	decl.Doc = p.leadComment
+	decl.Filename = p.filename
+	p.checkIdent(p.config.identPolicy.TypeName, "type")
 	decl.Ident = p.parseIdent()
-	p.expect(token.ASSIGN)
+	decl.Pos = decl.Ident.Pos
+	if p.tok == token.COLON && p.config.colonDecls {
+		p.next()
+	} else {
+		p.expect(token.ASSIGN)
+	}
+	decl.Annotations = p.parseAnnotations()
 	decl.Body = p.parseTypeDef()
+	decl.End = p.end
+	if p.config.rawSource && decl.Pos.Offset <= decl.End.Offset {
+		decl.RawSource = append([]byte(nil), p.src[decl.Pos.Offset:decl.End.Offset]...)
+	}
 	return
 }
 
 func (p *parser) parseFile() *ast.IDLFile {
+	p.trace("parseFile")
+
+	// import and extern decls, in any order
+	var imports, externs []string
+	for p.tok == token.IMPORT || p.tok == token.EXTERN {
+		if p.tok == token.IMPORT {
+			imports = append(imports, p.parseImport()...)
+		} else {
+			externs = append(externs, p.parseExtern())
+		}
+	}
+
+	if p.config.importsOnly {
+		return &ast.IDLFile{Imports: imports, Externs: externs}
+	}
 
-	// import decls
-	var imports []string
-	for p.tok == token.IMPORT {
-		imports = append(imports, p.parseImport())
+	// namespace decl
+	var namespace []string
+	if p.config.namespaces && p.tok == token.ANNOTATION && p.lit == "@namespace" {
+		namespace = p.parseNamespace()
 	}
 
 	// rest of body
 	var decls []ast.TypeDecl
+	seen := make(map[string]bool)
 	for p.tok != token.EOF {
-		decls = append(decls, p.parseDecl())
+		decl := p.parseDecl()
+		p.declare(seen, "type", decl.Ident)
+		decls = append(decls, decl)
 	}
 
 	return &ast.IDLFile{
 		Imports:   imports,
+		Externs:   externs,
 		TypeDecls: decls,
+		Comments:  p.comments,
+		Namespace: namespace,
 	}
 }
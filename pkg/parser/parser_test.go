@@ -1,14 +1,26 @@
 package parser_test
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 
 	"github.com/SafetyCulture/djinni-parser/pkg/ast"
 	"github.com/SafetyCulture/djinni-parser/pkg/parser"
+	"github.com/SafetyCulture/djinni-parser/pkg/token"
 )
 
+// ignorePos ignores node positions when comparing parsed trees.
+var ignorePos = cmpopts.IgnoreTypes(token.Pos{})
+
 func TestImports(t *testing.T) {
 	t.Parallel()
 	src := `
@@ -33,6 +45,186 @@ func TestImports(t *testing.T) {
 	}
 }
 
+func TestExterns(t *testing.T) {
+	t.Parallel()
+	src := `
+		@extern "first.yaml"
+		@import "common.djinni"
+		@extern "second.yaml"
+		my_record = record { id: i32; }
+	`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"first.yaml", "second.yaml"}, f.Externs); diff != "" {
+		t.Errorf("incorrect externs: %s", diff)
+	}
+	if diff := cmp.Diff([]string{"common.djinni"}, f.Imports); diff != "" {
+		t.Errorf("incorrect imports: %s", diff)
+	}
+	if len(f.TypeDecls) != 1 {
+		t.Errorf("incorrect number of decls; expected 1, got %d", len(f.TypeDecls))
+	}
+
+	_, err = parser.ParseFile("", "@extern other.yaml")
+	if err == nil {
+		t.Error("expected an error for an unquoted extern path")
+	}
+}
+
+func TestDegenerateImports(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		src  string
+		want string
+	}{
+		{`@import "`, ""},
+		{`@import "\"`, `\"`},
+		{"@import \"a.djinni\n", "a.djinni"},
+		{`@extern "`, ""},
+	}
+
+	for _, tt := range tests {
+		f, err := parser.ParseFile("", tt.src, parser.WithAllErrors())
+		if err == nil || !strings.Contains(err.Error(), "unterminated string literal") {
+			t.Errorf("%q: expected an unterminated string error, got %v", tt.src, err)
+		}
+		paths := append(f.Imports, f.Externs...)
+		if len(paths) != 1 || paths[0] != tt.want {
+			t.Errorf("%q: incorrect paths: %q", tt.src, paths)
+		}
+	}
+}
+
+func TestImportComments(t *testing.T) {
+	t.Parallel()
+	src := "# Shared types\n@import \"common.djinni\"\n# Generated\n@import \"gen.djinni\"\n"
+
+	f, err := parser.ParseFile("", src, parser.WithComments())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(f.Imports) != 2 || f.Imports[0] != "common.djinni" || f.Imports[1] != "gen.djinni" {
+		t.Errorf("incorrect imports: %q", f.Imports)
+	}
+
+	if len(f.Comments) != 2 {
+		t.Fatalf("incorrect number of comment groups; expected 2, got %d", len(f.Comments))
+	}
+	c := f.Comments[0].List[0]
+	if c.Text != "# Shared types" || c.Pos != (token.Pos{Offset: 0, Line: 1, Column: 1}) {
+		t.Errorf("incorrect first comment: %+v", c)
+	}
+	c = f.Comments[1].List[0]
+	if c.Text != "# Generated" || c.Pos != (token.Pos{Offset: 39, Line: 3, Column: 1}) {
+		t.Errorf("incorrect second comment: %+v", c)
+	}
+
+	f, err = parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Comments) != 0 {
+		t.Errorf("expected no comments without WithComments, got %d", len(f.Comments))
+	}
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestReaderSource(t *testing.T) {
+	t.Parallel()
+
+	f, err := parser.ParseFile("", strings.NewReader("my_record = record { id: i32; }"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.TypeDecls) != 1 {
+		t.Errorf("incorrect number of decls; expected 1, got %d", len(f.TypeDecls))
+	}
+
+	errRead := errors.New("connection reset")
+	f, err = parser.ParseFile("", errReader{errRead})
+	if !errors.Is(err, errRead) {
+		t.Errorf("expected the read error to be wrapped, got %v", err)
+	}
+	if f != nil {
+		t.Errorf("expected no file, got %+v", f)
+	}
+}
+
+func TestMultiImports(t *testing.T) {
+	t.Parallel()
+	src := `@import "a.djinni" "b.djinni"`
+
+	f, err := parser.ParseFile("", src, parser.WithMultiImports())
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff := cmp.Diff([]string{"a.djinni", "b.djinni"}, f.Imports)
+	if diff != "" {
+		t.Errorf("incorrect imports:\n%s", diff)
+	}
+
+	if _, err := parser.ParseFile("", src); err == nil {
+		t.Error("expected an error without WithMultiImports")
+	}
+}
+
+func TestNamespaces(t *testing.T) {
+	t.Parallel()
+	src := `
+		@import "common.djinni"
+		@namespace com.example.api;
+		my_record = record {}
+	`
+
+	f, err := parser.ParseFile("", src, parser.WithNamespaces())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"com", "example", "api"}, f.Namespace); diff != "" {
+		t.Errorf("incorrect namespace: %s", diff)
+	}
+	if len(f.Imports) != 1 || len(f.TypeDecls) != 1 {
+		t.Errorf("incorrect file: %+v", f)
+	}
+
+	if _, err := parser.ParseFile("", src); err == nil {
+		t.Error("expected an error without WithNamespaces")
+	}
+
+	_, err = parser.ParseFile("", "@namespace com.1example;", parser.WithNamespaces())
+	if err == nil || err.Error() != `1:16: namespace segment "1example" must not start with a digit` {
+		t.Errorf("incorrect error for an invalid segment: %v", err)
+	}
+}
+
+func TestColonDecls(t *testing.T) {
+	t.Parallel()
+	src := "my_record : record { id: i32; }"
+
+	f, err := parser.ParseFile("", src, parser.WithColonDecls())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.TypeDecls) != 1 || f.TypeDecls[0].Ident.Name != "my_record" {
+		t.Fatalf("incorrect decls: %#v", f.TypeDecls)
+	}
+	if r := f.TypeDecls[0].Body.(*ast.Record); len(r.Fields) != 1 {
+		t.Errorf("incorrect number of fields; expected 1, got %d", len(r.Fields))
+	}
+
+	if _, err := parser.ParseFile("", src); err == nil {
+		t.Error("expected an error without WithColonDecls")
+	}
+}
+
 func TestTypeDecls(t *testing.T) {
 	t.Parallel()
 
@@ -47,6 +239,31 @@ func TestTypeDecls(t *testing.T) {
 		{"EmptyEnum", "my_enum = enum {}", "my_enum", &ast.Enum{}},
 		{"EmptyFlags", "my_flags = flags {}", "my_flags", &ast.Enum{Flags: true}},
 		{"EmptyCPPInterface", "my_cpp_interface = interface +c {}", "my_cpp_interface", &ast.Interface{Ext: ast.Ext{CPP: true}}},
+		{"EmptyWasmInterface", "my_iface = interface +w {}", "my_iface", &ast.Interface{Ext: ast.Ext{Wasm: true}}},
+		{"EmptyNodeInterface", "my_iface = interface +n +c {}", "my_iface", &ast.Interface{Ext: ast.Ext{Node: true, CPP: true}}},
+		{"RecordWithFields", "my_record = record { id: i32; names: list<string>; }", "my_record", &ast.Record{
+			Fields: []ast.Field{
+				{Ident: ast.Ident{Name: "id"}, Type: ast.TypeExpr{Ident: ast.Ident{Name: "i32"}}},
+				{Ident: ast.Ident{Name: "names"}, Type: ast.TypeExpr{
+					Ident: ast.Ident{Name: "list"},
+					Args:  []ast.TypeExpr{{Ident: ast.Ident{Name: "string"}}},
+				}},
+			},
+		}},
+		{"RecordWithConsts", `my_record = record { const a: i32 = 42; const b: f64 = 1.5; const c: string = "c"; }`, "my_record", &ast.Record{
+			Consts: []ast.Const{
+				{Ident: ast.Ident{Name: "a"}, Type: ast.TypeExpr{Ident: ast.Ident{Name: "i32"}}, Value: int64(42), Raw: "42"},
+				{Ident: ast.Ident{Name: "b"}, Type: ast.TypeExpr{Ident: ast.Ident{Name: "f64"}}, Value: 1.5, Raw: "1.5"},
+				{Ident: ast.Ident{Name: "c"}, Type: ast.TypeExpr{Ident: ast.Ident{Name: "string"}}, Value: "c", Raw: `"c"`},
+			},
+		}},
+		{"InterfaceWithConst", "my_iface = interface +c { get(): i32; const max: i32 = 10; }", "my_iface", &ast.Interface{
+			Ext:     ast.Ext{CPP: true},
+			Methods: []ast.Method{{Ident: ast.Ident{Name: "get"}, Return: &ast.TypeExpr{Ident: ast.Ident{Name: "i32"}}}},
+			Consts: []ast.Const{
+				{Ident: ast.Ident{Name: "max"}, Type: ast.TypeExpr{Ident: ast.Ident{Name: "i32"}}, Value: int64(10), Raw: "10"},
+			},
+		}},
 	}
 
 	for _, tt := range tests {
@@ -67,10 +284,1709 @@ func TestTypeDecls(t *testing.T) {
 				t.Errorf("incorrect identifier: expected %q, got %q", tt.ident, d.Ident.Name)
 			}
 
-			diff := cmp.Diff(tt.want, d.Body)
+			diff := cmp.Diff(tt.want, d.Body, ignorePos)
 			if diff != "" {
 				t.Fatalf(diff)
 			}
 		})
 	}
 }
+
+func TestSingleLineFields(t *testing.T) {
+	t.Parallel()
+	src := "my_record = record {\n\t# doc\n\ta: i32; b: i32;c:list<i32>; const d: i32 = 1; e: bool;\n}"
+
+	f, err := parser.ParseFile("", src, parser.WithComments())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := f.TypeDecls[0].Body.(*ast.Record)
+	var names []string
+	for _, field := range r.Fields {
+		names = append(names, field.Ident.Name)
+	}
+	if diff := cmp.Diff([]string{"a", "b", "c", "e"}, names); diff != "" {
+		t.Errorf("incorrect fields: %s", diff)
+	}
+	if len(r.Consts) != 1 {
+		t.Errorf("incorrect number of consts; expected 1, got %d", len(r.Consts))
+	}
+	if r.Fields[0].Doc.Text() != "doc" || r.Fields[1].Doc != nil {
+		t.Errorf("the doc comment should only document the first field")
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	t.Parallel()
+	src := `my_record = @json record +c {}`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.TypeDecls) != 1 {
+		t.Fatalf("incorrect number of decls; expected 1, got %d", len(f.TypeDecls))
+	}
+
+	d := f.TypeDecls[0]
+	diff := cmp.Diff([]ast.Annotation{{Name: "json"}}, d.Annotations, ignorePos)
+	if diff != "" {
+		t.Errorf("incorrect annotations:\n%s", diff)
+	}
+	diff = cmp.Diff(&ast.Record{Ext: ast.Ext{CPP: true}}, d.Body, ignorePos)
+	if diff != "" {
+		t.Errorf("incorrect body:\n%s", diff)
+	}
+}
+
+func TestNullConst(t *testing.T) {
+	t.Parallel()
+
+	f, err := parser.ParseFile("", "my_record = record { const c: optional<i32> = null; }")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := f.TypeDecls[0].Body.(*ast.Record)
+	if len(r.Consts) != 1 {
+		t.Fatalf("incorrect number of consts; expected 1, got %d", len(r.Consts))
+	}
+	if _, ok := r.Consts[0].Value.(ast.NullValue); !ok {
+		t.Errorf("incorrect value: expected ast.NullValue, got %#v", r.Consts[0].Value)
+	}
+
+	_, err = parser.ParseFile("", "my_record = record { const c: i32 = null; }")
+	if err == nil {
+		t.Fatal("expected an error for a null non-optional const")
+	}
+	if want := "1:37: null is only valid for optional constants, got i32"; err.Error() != want {
+		t.Errorf("incorrect error: expected %q, got %q", want, err)
+	}
+}
+
+func TestMethodExtHints(t *testing.T) {
+	t.Parallel()
+	src := `
+		my_interface = interface +c +j {
+			plain(): i32;
+			hinted(): i32 +c;
+		}
+	`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i := f.TypeDecls[0].Body.(*ast.Interface)
+	if len(i.Methods) != 2 {
+		t.Fatalf("incorrect number of methods; expected 2, got %d", len(i.Methods))
+	}
+	if i.Methods[0].Ext != (ast.Ext{}) {
+		t.Errorf("incorrect ext for plain method: %+v", i.Methods[0].Ext)
+	}
+	if i.Methods[1].Ext != (ast.Ext{CPP: true}) {
+		t.Errorf("incorrect ext for hinted method: %+v", i.Methods[1].Ext)
+	}
+}
+
+func TestMethods(t *testing.T) {
+	t.Parallel()
+	src := `
+		my_interface = interface +c {
+			get_value(key: string): i32;
+			set_value(key: string, value: list<i32>);
+			foo();
+		}
+	`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &ast.Interface{
+		Ext: ast.Ext{CPP: true},
+		Methods: []ast.Method{
+			{
+				Ident: ast.Ident{Name: "get_value"},
+				Params: []ast.Field{
+					{Ident: ast.Ident{Name: "key"}, Type: ast.TypeExpr{Ident: ast.Ident{Name: "string"}}},
+				},
+				Return: &ast.TypeExpr{Ident: ast.Ident{Name: "i32"}},
+			},
+			{
+				Ident: ast.Ident{Name: "set_value"},
+				Params: []ast.Field{
+					{Ident: ast.Ident{Name: "key"}, Type: ast.TypeExpr{Ident: ast.Ident{Name: "string"}}},
+					{Ident: ast.Ident{Name: "value"}, Type: ast.TypeExpr{
+						Ident: ast.Ident{Name: "list"},
+						Args:  []ast.TypeExpr{{Ident: ast.Ident{Name: "i32"}}},
+					}},
+				},
+			},
+			{Ident: ast.Ident{Name: "foo"}},
+		},
+	}
+
+	diff := cmp.Diff(want, f.TypeDecls[0].Body, ignorePos)
+	if diff != "" {
+		t.Fatalf(diff)
+	}
+}
+
+func TestMethodTypes(t *testing.T) {
+	t.Parallel()
+	src := `
+		my_interface = interface +c {
+			merge(values: map<string, list<i32>>, keys: set<string>): optional<list<string>>;
+			const lookup(id: i64): optional<my_record>;
+		}
+	`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	methods := f.TypeDecls[0].Body.(*ast.Interface).Methods
+	if len(methods) != 2 {
+		t.Fatalf("incorrect number of methods; expected 2, got %d", len(methods))
+	}
+
+	tests := [...]struct {
+		name string
+		got  string
+		want string
+	}{
+		{"first param", methods[0].Params[0].Type.String(), "map<string, list<i32>>"},
+		{"second param", methods[0].Params[1].Type.String(), "set<string>"},
+		{"return", methods[0].Return.String(), "optional<list<string>>"},
+		{"const return", methods[1].Return.String(), "optional<my_record>"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: incorrect type: expected %s, got %s", tt.name, tt.want, tt.got)
+		}
+	}
+
+	if !methods[1].Const {
+		t.Error("expected lookup to be a const method")
+	}
+}
+
+func TestMethodReturns(t *testing.T) {
+	t.Parallel()
+	src := "my_interface = interface +c { a(); b(): i32; c(): optional<string>; }"
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	methods := f.TypeDecls[0].Body.(*ast.Interface).Methods
+	i32 := ast.TypeExpr{Ident: ast.Ident{Name: "i32"}}
+	optional := ast.TypeExpr{
+		Ident: ast.Ident{Name: "optional"},
+		Args:  []ast.TypeExpr{{Ident: ast.Ident{Name: "string"}}},
+	}
+	want := []*ast.TypeExpr{nil, &i32, &optional}
+	for i, m := range methods {
+		if diff := cmp.Diff(want[i], m.Return, ignorePos); diff != "" {
+			t.Errorf("%s: incorrect return type: %s", m.Ident.Name, diff)
+		}
+	}
+}
+
+func TestIdentPolicy(t *testing.T) {
+	t.Parallel()
+	policy := parser.WithIdentPolicy(parser.IdentPolicy{
+		TypeName: regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`),
+	})
+
+	if _, err := parser.ParseFile("", "MyRecord = record { some_field: i32; }", policy); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := parser.ParseFile("", "my_record = record {}", policy)
+	if err == nil {
+		t.Fatal("expected an error for a snake_case type name")
+	}
+	if want := `1:1: type name "my_record" does not match ^[A-Z][A-Za-z0-9]*$`; err.Error() != want {
+		t.Errorf("incorrect error: expected %q, got %q", want, err)
+	}
+}
+
+func TestMethodModifiers(t *testing.T) {
+	t.Parallel()
+	src := `
+		my_interface = interface +c {
+			static get_instance(): my_interface;
+			const do_thing();
+			const max_size: i32 = 10;
+		}
+	`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &ast.Interface{
+		Ext: ast.Ext{CPP: true},
+		Methods: []ast.Method{
+			{
+				Ident:  ast.Ident{Name: "get_instance"},
+				Return: &ast.TypeExpr{Ident: ast.Ident{Name: "my_interface"}},
+				Static: true,
+			},
+			{Ident: ast.Ident{Name: "do_thing"}, Const: true},
+		},
+		Consts: []ast.Const{
+			{Ident: ast.Ident{Name: "max_size"}, Type: ast.TypeExpr{Ident: ast.Ident{Name: "i32"}}, Value: int64(10), Raw: "10"},
+		},
+	}
+
+	diff := cmp.Diff(want, f.TypeDecls[0].Body, ignorePos)
+	if diff != "" {
+		t.Fatalf(diff)
+	}
+
+	_, err = parser.ParseFile("", "my_interface = interface +j { static get_instance(): my_interface; }")
+	if err == nil {
+		t.Fatal("expected an error for a static method without +c")
+	}
+}
+
+func TestDocComments(t *testing.T) {
+	t.Parallel()
+	src := `
+		# a detached comment
+
+		# my record
+		my_record = record {
+			# this is the id
+			id: i32;
+
+			# not the docs for name
+
+			name: string;
+			# a constant
+			const c: i32 = 1;
+		}
+
+		my_interface = interface +c {
+			# does something
+			do_thing();
+			# does something const
+			const do_const_thing();
+		}
+	`
+
+	f, err := parser.ParseFile("", src, parser.WithComments())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := f.TypeDecls[0].Body.(*ast.Record)
+	i := f.TypeDecls[1].Body.(*ast.Interface)
+
+	tests := [...]struct {
+		name string
+		doc  *ast.CommentGroup
+		want string
+	}{
+		{"TypeDecl", f.TypeDecls[0].Doc, "my record"},
+		{"Field", r.Fields[0].Doc, "this is the id"},
+		{"DetachedField", r.Fields[1].Doc, ""},
+		{"Const", r.Consts[0].Doc, "a constant"},
+		{"UndocumentedTypeDecl", f.TypeDecls[1].Doc, ""},
+		{"Method", i.Methods[0].Doc, "does something"},
+		{"ConstMethod", i.Methods[1].Doc, "does something const"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.doc.Text(); got != tt.want {
+			t.Errorf("%s: incorrect doc: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestUnicodeIdents(t *testing.T) {
+	t.Parallel()
+	src := "caf\u00e9 = record { gr\u00f6\u00dfe: i32; \u540d\u524d2: string; }"
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if name := f.TypeDecls[0].Ident.Name; name != "caf\u00e9" {
+		t.Errorf("incorrect type name: %q", name)
+	}
+	r := f.TypeDecls[0].Body.(*ast.Record)
+	if name := r.Fields[0].Ident.Name; name != "gr\u00f6\u00dfe" {
+		t.Errorf("incorrect field name: %q", name)
+	}
+	if name := r.Fields[1].Ident.Name; name != "\u540d\u524d2" {
+		t.Errorf("incorrect field name: %q", name)
+	}
+}
+
+func TestAdjacentDeclDocs(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name     string
+		src      string
+		foo, bar string
+	}{
+		{"BothDocumented", "# foo doc\nfoo = record {}\n# bar doc\nbar = enum {}\n", "foo doc", "bar doc"},
+		{"FirstOnly", "# foo doc\nfoo = record {}\nbar = enum {}\n", "foo doc", ""},
+		{"Trailing", "foo = record {} # trailing\nbar = enum {}\n", "", ""},
+		{"Detached", "foo = record {}\n# floating\n\nbar = enum {}\n", "", ""},
+	}
+
+	for _, tt := range tests {
+		f, err := parser.ParseFile("", tt.src, parser.WithComments())
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := f.TypeDecls[0].Doc.Text(); got != tt.foo {
+			t.Errorf("%s: incorrect doc for foo: expected %q, got %q", tt.name, tt.foo, got)
+		}
+		if got := f.TypeDecls[1].Doc.Text(); got != tt.bar {
+			t.Errorf("%s: incorrect doc for bar: expected %q, got %q", tt.name, tt.bar, got)
+		}
+		if len(f.Comments) == 0 {
+			t.Errorf("%s: expected the comments to be kept in the file", tt.name)
+		}
+	}
+}
+
+func TestFileComments(t *testing.T) {
+	t.Parallel()
+	src := "# detached\n\n# doc\ncolor = enum {\n\tred; # trailing\n\t# inside\n}\n# last\n"
+
+	f, err := parser.ParseFile("", src, parser.WithComments())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every comment is kept in source order, attached to a node or not
+	want := []ast.Comment{
+		{Pos: token.Pos{Offset: 0, Line: 1, Column: 1}, Text: "# detached"},
+		{Pos: token.Pos{Offset: 12, Line: 3, Column: 1}, Text: "# doc"},
+		{Pos: token.Pos{Offset: 39, Line: 5, Column: 7}, Text: "# trailing"},
+		{Pos: token.Pos{Offset: 51, Line: 6, Column: 2}, Text: "# inside"},
+		{Pos: token.Pos{Offset: 62, Line: 8, Column: 1}, Text: "# last"},
+	}
+	var got []ast.Comment
+	for _, g := range f.Comments {
+		for _, c := range g.List {
+			got = append(got, *c)
+		}
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("incorrect comments: %s", diff)
+	}
+
+	// the doc and trailing comments are also attached to their nodes
+	e := f.TypeDecls[0].Body.(*ast.Enum)
+	if f.TypeDecls[0].Doc != f.Comments[1] || e.Options[0].Comment != f.Comments[2] {
+		t.Error("expected the attached comments to share their groups with the file")
+	}
+}
+
+func TestLineEndings(t *testing.T) {
+	t.Parallel()
+	src := "# my record\nmy_record = record {\n\t# the id\n\tid: i32; # trailing\n\tname: string;\n}\n"
+
+	want, err := parser.ParseFile("", src, parser.WithComments())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, eol := range []string{"\r\n", "\r"} {
+		got, err := parser.ParseFile("", strings.ReplaceAll(src, "\n", eol), parser.WithComments())
+		if err != nil {
+			t.Fatalf("%q: %v", eol, err)
+		}
+		if diff := cmp.Diff(want, got, ignorePos); diff != "" {
+			t.Errorf("%q: incorrect file: %s", eol, diff)
+		}
+		field := got.TypeDecls[0].Body.(*ast.Record).Fields[1]
+		if field.Ident.Pos.Line != 5 || field.Ident.Pos.Column != 2 {
+			t.Errorf("%q: incorrect position of name: %+v", eol, field.Ident.Pos)
+		}
+	}
+}
+
+func TestDeriving(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name string
+		src  string
+		want ast.Deriving
+	}{
+		{"Trailing", "my_record = record { id: i32; } deriving (eq, ord)", ast.Deriving{Eq: true, Ord: true}},
+		{"Inline", "my_record = record +j deriving (parcelable) { id: i32; }", ast.Deriving{Parcelable: true}},
+		{"Empty", "my_record = record {} deriving ()", ast.Deriving{}},
+		{"None", "my_record = record {}", ast.Deriving{}},
+	}
+
+	for _, tt := range tests {
+		f, err := parser.ParseFile("", tt.src)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		r := f.TypeDecls[0].Body.(*ast.Record)
+		if r.Deriving != tt.want {
+			t.Errorf("%s: incorrect deriving: expected %+v, got %+v", tt.name, tt.want, r.Deriving)
+		}
+	}
+
+	src := `
+		my_record = record {} deriving (eq, hash)
+		other_record = record { id: i32; }
+	`
+	f, err := parser.ParseFile("", src)
+	if err == nil || err.Error() != `2:39: unknown deriving trait "hash"` {
+		t.Errorf("incorrect error for unknown trait: %v", err)
+	}
+	if len(f.TypeDecls) != 2 {
+		t.Fatalf("incorrect number of decls; expected 2, got %d", len(f.TypeDecls))
+	}
+	if r := f.TypeDecls[0].Body.(*ast.Record); !r.Deriving.Eq {
+		t.Errorf("expected eq to be derived despite the unknown trait")
+	}
+
+	f, err = parser.ParseFile("", "my_record = record deriving (eq) { id: i32; } deriving (ord)")
+	if err == nil || err.Error() != "1:47: duplicate deriving clause, also at line 1" {
+		t.Errorf("incorrect error for two deriving clauses: %v", err)
+	}
+	if r := f.TypeDecls[0].Body.(*ast.Record); r.Deriving != (ast.Deriving{Eq: true, Ord: true}) {
+		t.Errorf("expected the traits of both clauses, got %+v", r.Deriving)
+	}
+}
+
+func TestMapTypes(t *testing.T) {
+	t.Parallel()
+
+	i32 := ast.TypeExpr{Ident: ast.Ident{Name: "i32"}}
+	str := ast.TypeExpr{Ident: ast.Ident{Name: "string"}}
+	list := func(t ast.TypeExpr) ast.TypeExpr {
+		return ast.TypeExpr{Ident: ast.Ident{Name: "list"}, Args: []ast.TypeExpr{t}}
+	}
+	mapOf := func(k, v ast.TypeExpr) ast.TypeExpr {
+		return ast.TypeExpr{Ident: ast.Ident{Name: "map"}, Args: []ast.TypeExpr{k, v}}
+	}
+
+	tests := [...]struct {
+		typ  string
+		want ast.TypeExpr
+	}{
+		{"map<string, list<i32>>", mapOf(str, list(i32))},
+		{"map<string, map<string, i32>>", mapOf(str, mapOf(str, i32))},
+		{"map<list<string>, i32>", mapOf(list(str), i32)},
+	}
+
+	for _, tt := range tests {
+		f, err := parser.ParseFile("", "my_record = record { m: "+tt.typ+"; }")
+		if err != nil {
+			t.Errorf("%s: %v", tt.typ, err)
+			continue
+		}
+		got := f.TypeDecls[0].Body.(*ast.Record).Fields[0].Type
+		diff := cmp.Diff(tt.want, got, ignorePos)
+		if diff != "" {
+			t.Errorf("%s: incorrect type:\n%s", tt.typ, diff)
+		}
+	}
+}
+
+func TestNestedTypes(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		typ   string
+		names []string // the type name at each level, outermost first
+	}{
+		{"optional<list<optional<string>>>", []string{"optional", "list", "optional", "string"}},
+		{"list<list<i32>>", []string{"list", "list", "i32"}},
+		{"set<list<string>>", []string{"set", "list", "string"}},
+		{"list<set<optional<my_record>>>", []string{"list", "set", "optional", "my_record"}},
+	}
+
+	for _, tt := range tests {
+		f, err := parser.ParseFile("", "my_record = record { m: "+tt.typ+"; }")
+		if err != nil {
+			t.Errorf("%s: %v", tt.typ, err)
+			continue
+		}
+		typ := f.TypeDecls[0].Body.(*ast.Record).Fields[0].Type
+		for i, name := range tt.names {
+			if typ.Ident.Name != name {
+				t.Errorf("%s: level %d: expected %s, got %s", tt.typ, i, name, typ.Ident.Name)
+				break
+			}
+			if i == len(tt.names)-1 {
+				if len(typ.Args) != 0 {
+					t.Errorf("%s: level %d: expected no args, got %d", tt.typ, i, len(typ.Args))
+				}
+				break
+			}
+			if len(typ.Args) != 1 {
+				t.Errorf("%s: level %d: expected 1 arg, got %d", tt.typ, i, len(typ.Args))
+				break
+			}
+			typ = typ.Args[0]
+		}
+	}
+}
+
+func TestTypeExprString(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		typ  string
+		want string
+	}{
+		{"map<string,i32>", "map<string, i32>"},
+		{"map < string ,list< i32 > >", "map<string, list<i32>>"},
+		{"optional<\n\tmap<set<string>,\tmap<i32,list<optional<bool>>>>\n>", "optional<map<set<string>, map<i32, list<optional<bool>>>>>"},
+	}
+
+	for _, tt := range tests {
+		f, err := parser.ParseFile("", "my_record = record { m: "+tt.typ+"; }")
+		if err != nil {
+			t.Errorf("%q: %v", tt.typ, err)
+			continue
+		}
+		if got := f.TypeDecls[0].Body.(*ast.Record).Fields[0].Type.String(); got != tt.want {
+			t.Errorf("%q: incorrect string: expected %s, got %s", tt.typ, tt.want, got)
+		}
+	}
+}
+
+func TestParseWithTrace(t *testing.T) {
+	t.Parallel()
+
+	_, trace, err := parser.ParseWithTrace("", "my_enum = enum {}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"parseFile", "parseDecl", "parseIdent", "parseAnnotations", "parseTypeDef", "parseEnum"}
+	diff := cmp.Diff(want, trace)
+	if diff != "" {
+		t.Errorf("incorrect trace:\n%s", diff)
+	}
+}
+
+func TestUnterminatedString(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]string{
+		"my_record = record { const s: string = \"oops; }",
+		"my_record = record { const s: string = \"oops\\\"; }",
+		"my_record = record { const s: string = \"",
+	}
+
+	for _, src := range tests {
+		f, err := parser.ParseFile("", src, parser.WithAllErrors())
+		list, ok := err.(parser.ErrorList)
+		if !ok || len(list) == 0 {
+			t.Errorf("%s: expected errors, got %v", src, err)
+			continue
+		}
+		if e := list[0]; e.Pos.Column != 40 || e.Msg != "unterminated string literal" {
+			t.Errorf("%s: incorrect error: %v", src, e)
+		}
+		for _, e := range list[1:] {
+			if strings.HasPrefix(e.Msg, "invalid string") {
+				t.Errorf("%s: the string was reported twice: %v", src, e)
+			}
+		}
+		if f == nil || len(f.TypeDecls) != 1 {
+			t.Errorf("%s: expected the partial record", src)
+		}
+	}
+}
+
+func TestNegativeConst(t *testing.T) {
+	t.Parallel()
+	src := `my_record = record {
+		const min: i32 = -42;
+		const x: f64 = - 3.14;
+		const lowest: i64 = -9223372036854775808;
+		const maybe: optional<i8> = -1;
+		const point: point = { x = -1, y = 2 };
+	}`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []interface{}{int64(-42), -3.14, int64(-9223372036854775808), int64(-1)}
+	for i, c := range f.TypeDecls[0].Body.(*ast.Record).Consts[:len(want)] {
+		if c.Value != want[i] {
+			t.Errorf("%s: incorrect value: expected %v, got %#v", c.Ident.Name, want[i], c.Value)
+		}
+	}
+
+	_, err = parser.ParseFile("", `my_record = record { const s: i32 = -"s"; }`)
+	if err == nil || err.Error() != `1:38: expected number after '-', got "STRING"` {
+		t.Errorf("incorrect error for a negative string: %v", err)
+	}
+
+	tests := [...]struct {
+		src string
+		err string
+	}{
+		{"const s: string = -1;", "1:40: '-' is only valid for numeric constants, got string"},
+		{"const b: optional<bool> = -1;", "1:48: '-' is only valid for numeric constants, got optional<bool>"},
+		{"const l: list<string> = [-1];", "1:47: '-' is only valid for numeric constants, got string"},
+	}
+	for _, tt := range tests {
+		_, err := parser.ParseFile("", "my_record = record { "+tt.src+" }")
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%s: incorrect error: expected %q, got %v", tt.src, tt.err, err)
+		}
+	}
+}
+
+func TestFloatConst(t *testing.T) {
+	t.Parallel()
+	src := `my_record = record {
+		const a: f64 = 1.5e10;
+		const b: f64 = 2E-3;
+		const c: f64 = .5;
+		const d: f64 = -.25e+2;
+	}`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []float64{1.5e10, 2e-3, 0.5, -25}
+	for i, c := range f.TypeDecls[0].Body.(*ast.Record).Consts {
+		if c.Value != want[i] {
+			t.Errorf("%s: incorrect value: expected %v, got %#v", c.Ident.Name, want[i], c.Value)
+		}
+	}
+}
+
+func TestIntegerBases(t *testing.T) {
+	t.Parallel()
+	src := `my_record = record {
+		const mask: i32 = 0xFF;
+		const bits: i32 = 0b1010;
+		const mode: i32 = 0o755;
+		const neg: i64 = -0x10;
+		const padded: i32 = 010;
+		const nine: i32 = 09;
+		const zero: i32 = 0;
+	}`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []int64{255, 10, 493, -16, 10, 9, 0}
+	for i, c := range f.TypeDecls[0].Body.(*ast.Record).Consts {
+		if c.Value != want[i] {
+			t.Errorf("%s: incorrect value: expected %d, got %#v", c.Ident.Name, want[i], c.Value)
+		}
+	}
+
+	_, err = parser.ParseFile("", "my_record = record { const c: i32 = 0x; }")
+	if err == nil || err.Error() != "1:37: hexadecimal literal has no digits" {
+		t.Errorf("incorrect error for a prefix without digits: %v", err)
+	}
+}
+
+func TestRawValues(t *testing.T) {
+	t.Parallel()
+	src := `
+		my_record = record {
+			bits: i32 = 0x0F;
+			const mask: i32 = 0xFF;
+			const ratio: f64 = 1.50;
+			const offset: i32 = - 010;
+			const label: string = "\x41";
+			const origin: point = { x = 1,
+				y = 2 };
+		}
+	`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := f.TypeDecls[0].Body.(*ast.Record)
+	if got := r.Fields[0].DefaultRaw; got != "0x0F" {
+		t.Errorf("incorrect raw default: %q", got)
+	}
+	want := []string{"0xFF", "1.50", "- 010", `"\x41"`, "{ x = 1,\n\t\t\t\ty = 2 }"}
+	for i, c := range r.Consts {
+		if c.Raw != want[i] {
+			t.Errorf("%s: incorrect raw value: expected %q, got %q", c.Ident.Name, want[i], c.Raw)
+		}
+	}
+}
+
+func TestBoolConst(t *testing.T) {
+	t.Parallel()
+	src := "my_record = record { enabled: bool; const on: bool = true; const off: bool = false; }"
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := f.TypeDecls[0].Body.(*ast.Record)
+	if typ := r.Fields[0].Type.String(); typ != "bool" {
+		t.Errorf("incorrect field type: expected bool, got %s", typ)
+	}
+	if v := r.Consts[0].Value; v != true {
+		t.Errorf("incorrect value for on: %#v", v)
+	}
+	if v := r.Consts[1].Value; v != false {
+		t.Errorf("incorrect value for off: %#v", v)
+	}
+}
+
+func TestFieldDefaults(t *testing.T) {
+	t.Parallel()
+	src := `
+		my_record = record {
+			score: i32 = 0;
+			name: string = "anonymous";
+			nickname: optional<string> = null;
+			id: i64;
+		}
+	`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := f.TypeDecls[0].Body.(*ast.Record)
+	want := []interface{}{int64(0), "anonymous", ast.NullValue{}, nil}
+	for i, field := range r.Fields {
+		if diff := cmp.Diff(want[i], field.Default); diff != "" {
+			t.Errorf("%s: incorrect default: %s", field.Ident.Name, diff)
+		}
+	}
+
+	_, err = parser.ParseFile("", "my_record = record { score: i32 = null; }")
+	if err == nil || err.Error() != "1:35: null is only valid for optional fields, got i32" {
+		t.Errorf("incorrect error for a null default: %v", err)
+	}
+
+	_, err = parser.ParseFile("", "my_record = record { score: i32 = 0; }", parser.WithStrictDjinni())
+	if err == nil || err.Error() != "1:33: field defaults are not standard Djinni" {
+		t.Errorf("incorrect error in strict mode: %v", err)
+	}
+}
+
+func TestConstRefs(t *testing.T) {
+	t.Parallel()
+	src := `
+		my_record = record {
+			const base: i32 = 1;
+			const doubled: i32 = base;
+			const default_color: color = color.red;
+		}
+	`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := f.TypeDecls[0].Body.(*ast.Record)
+	want := []interface{}{
+		int64(1),
+		ast.Ref{Ident: ast.Ident{Name: "base"}},
+		ast.Ref{Scope: &ast.Ident{Name: "color"}, Ident: ast.Ident{Name: "red"}},
+	}
+	for i, c := range r.Consts {
+		if diff := cmp.Diff(want[i], c.Value, ignorePos); diff != "" {
+			t.Errorf("%s: incorrect value: %s", c.Ident.Name, diff)
+		}
+	}
+	if s := r.Consts[2].Value.(ast.Ref).String(); s != "color.red" {
+		t.Errorf("incorrect string for the qualified reference: %s", s)
+	}
+}
+
+func TestRecordLiteralConst(t *testing.T) {
+	t.Parallel()
+	src := `
+		my_record = record {
+			const c: other_record = {
+				id = 1,
+				name = "name",
+				nested = { value = 1.5, },
+			};
+		}
+	`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := ast.RecordLiteral{
+		Fields: []ast.FieldValue{
+			{Ident: ast.Ident{Name: "id"}, Value: int64(1)},
+			{Ident: ast.Ident{Name: "name"}, Value: "name"},
+			{Ident: ast.Ident{Name: "nested"}, Value: ast.RecordLiteral{
+				Fields: []ast.FieldValue{{Ident: ast.Ident{Name: "value"}, Value: 1.5}},
+			}},
+		},
+	}
+
+	got := f.TypeDecls[0].Body.(*ast.Record).Consts[0].Value
+	diff := cmp.Diff(want, got, ignorePos)
+	if diff != "" {
+		t.Fatalf(diff)
+	}
+
+	_, err = parser.ParseFile("", "my_record = record { const c: other_record = { id = , name = \"name\" }; }")
+	if err == nil || err.Error() != "1:53: missing value for field id" {
+		t.Errorf("incorrect error for a missing field value: %v", err)
+	}
+}
+
+func TestStringEscapes(t *testing.T) {
+	t.Parallel()
+	src := `my_record = record {
+		const a: string = "line1\nline2";
+		const b: string = "he said \"hi\"";
+		const c: string = "tab\tand backslash\\";
+	}`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"line1\nline2", `he said "hi"`, "tab\tand backslash\\"}
+	for i, c := range f.TypeDecls[0].Body.(*ast.Record).Consts {
+		if c.Value != want[i] {
+			t.Errorf("%s: incorrect value: expected %q, got %q", c.Ident.Name, want[i], c.Value)
+		}
+	}
+
+	_, err = parser.ParseFile("", `my_record = record { const a: string = "\q"; }`)
+	if err == nil || err.Error() != `1:40: invalid string "\q"` {
+		t.Errorf("incorrect error for an invalid escape: %v", err)
+	}
+}
+
+func TestMapConst(t *testing.T) {
+	t.Parallel()
+	src := `
+		my_record = record {
+			const empty: map<string, i32> = {};
+			const defaults: map<string, map<i32, string>> = {
+				"b": { 1: "one" },
+				"a": {},
+			};
+		}
+	`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	consts := f.TypeDecls[0].Body.(*ast.Record).Consts
+	want := []interface{}{
+		ast.MapValue{},
+		ast.MapValue{Entries: []ast.MapEntry{
+			{Key: "b", Value: ast.MapValue{Entries: []ast.MapEntry{{Key: int64(1), Value: "one"}}}},
+			{Key: "a", Value: ast.MapValue{}},
+		}},
+	}
+	for i, c := range consts {
+		if diff := cmp.Diff(want[i], c.Value, ignorePos); diff != "" {
+			t.Errorf("%s: incorrect value: %s", c.Ident.Name, diff)
+		}
+	}
+
+	_, err = parser.ParseFile("", `my_record = record { const c: map<string, i32> = { "a": 1, "a": 2 }; }`)
+	if err == nil || err.Error() != `1:60: duplicate key "a" in map literal` {
+		t.Errorf("incorrect error for a duplicate key: %v", err)
+	}
+}
+
+func TestContainerConstTypes(t *testing.T) {
+	t.Parallel()
+	src := `
+		my_record = record {
+			const xs: list<i32> = defaults;
+			const names: set<string> = defaults;
+		}
+	`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	consts := f.TypeDecls[0].Body.(*ast.Record).Consts
+	want := []ast.TypeExpr{
+		{Ident: ast.Ident{Name: "list"}, Args: []ast.TypeExpr{{Ident: ast.Ident{Name: "i32"}}}},
+		{Ident: ast.Ident{Name: "set"}, Args: []ast.TypeExpr{{Ident: ast.Ident{Name: "string"}}}},
+	}
+	if len(consts) != len(want) {
+		t.Fatalf("incorrect number of consts; expected %d, got %d", len(want), len(consts))
+	}
+	for i, c := range consts {
+		if diff := cmp.Diff(want[i], c.Type, ignorePos); diff != "" {
+			t.Errorf("%s: incorrect type: %s", c.Ident.Name, diff)
+		}
+	}
+}
+
+func TestListConst(t *testing.T) {
+	t.Parallel()
+	src := `
+		my_record = record {
+			const xs: list<i32> = [1, 2, 3];
+			const empty: set<string> = [];
+			const nested: list<list<string>> = [["a", "b",], [],];
+			const refs: list<my_enum> = [my_enum.first, { id = 1 }];
+		}
+	`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	consts := f.TypeDecls[0].Body.(*ast.Record).Consts
+	want := []interface{}{
+		[]interface{}{int64(1), int64(2), int64(3)},
+		[]interface{}{},
+		[]interface{}{[]interface{}{"a", "b"}, []interface{}{}},
+		[]interface{}{
+			ast.Ref{Scope: &ast.Ident{Name: "my_enum"}, Ident: ast.Ident{Name: "first"}},
+			ast.RecordLiteral{Fields: []ast.FieldValue{{Ident: ast.Ident{Name: "id"}, Value: int64(1)}}},
+		},
+	}
+	if len(consts) != len(want) {
+		t.Fatalf("incorrect number of consts; expected %d, got %d", len(want), len(consts))
+	}
+	for i, c := range consts {
+		if diff := cmp.Diff(want[i], c.Value, ignorePos); diff != "" {
+			t.Errorf("%s: incorrect value: %s", c.Ident.Name, diff)
+		}
+	}
+
+	_, err = parser.ParseFile("", "my_record = record { const xs: list<i32> = [1 2]; }")
+	if err == nil || err.Error() != `1:47: expected "]", got "INT"` {
+		t.Errorf("incorrect error for a missing comma: %v", err)
+	}
+}
+
+func TestEnumOptions(t *testing.T) {
+	t.Parallel()
+	src := `
+		my_enum = enum {
+			# the first option
+			first;
+			second;
+		}
+	`
+
+	f, err := parser.ParseFile("", src, parser.WithComments())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := f.TypeDecls[0].Body.(*ast.Enum)
+	if len(e.Options) != 2 {
+		t.Fatalf("incorrect number of options; expected 2, got %d", len(e.Options))
+	}
+	if e.Options[0].Ident.Name != "first" || e.Options[1].Ident.Name != "second" {
+		t.Errorf("incorrect options: %+v", e.Options)
+	}
+	if doc := e.Options[0].Doc.Text(); doc != "the first option" {
+		t.Errorf("incorrect doc: %q", doc)
+	}
+}
+
+func TestCommaSeparatedEnumOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name string
+		src  string
+	}{
+		{"Semicolons", "e = enum { a; b; c; }"},
+		{"Commas", "e = enum { a, b, c }"},
+		{"TrailingComma", "e = enum { a, b, c, }"},
+		{"Mixed", "e = enum { a; b, c }"},
+		{"Values", "e = enum { a = 1, b, c = 3 }"},
+	}
+
+	for _, tt := range tests {
+		f, err := parser.ParseFile("", tt.src)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		var names []string
+		for _, o := range f.TypeDecls[0].Body.(*ast.Enum).Options {
+			names = append(names, o.Ident.Name)
+		}
+		if diff := cmp.Diff([]string{"a", "b", "c"}, names); diff != "" {
+			t.Errorf("%s: incorrect options: %s", tt.name, diff)
+		}
+	}
+
+	errTests := [...]struct {
+		name string
+		src  string
+		opts []parser.Option
+		want string
+	}{
+		{"MissingSeparator", "e = enum { a b }", nil, `1:14: expected ";", got "IDENT"`},
+		{"StrictComma", "e = enum { a, b; }", []parser.Option{parser.WithStrictDjinni()}, `1:13: expected ";", got ","`},
+		{"StrictLast", "e = enum { a; b }", []parser.Option{parser.WithStrictDjinni()}, `1:17: expected ";", got "}"`},
+	}
+	for _, tt := range errTests {
+		_, err := parser.ParseFile("", tt.src, tt.opts...)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%s: expected error %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestEnumOptionValues(t *testing.T) {
+	t.Parallel()
+	src := "color = enum { red = 0; green; blue = 0x10; }"
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zero, sixteen := 0, 16
+	want := []*int{&zero, nil, &sixteen}
+	for i, o := range f.TypeDecls[0].Body.(*ast.Enum).Options {
+		if diff := cmp.Diff(want[i], o.Value); diff != "" {
+			t.Errorf("%s: incorrect value: %s", o.Ident.Name, diff)
+		}
+	}
+
+	_, err = parser.ParseFile("", `color = enum { red = "red"; }`)
+	if err == nil || err.Error() != `1:22: expected value for option red, got "STRING"` {
+		t.Errorf("incorrect error for a string value: %v", err)
+	}
+}
+
+func TestFlagsAllNone(t *testing.T) {
+	t.Parallel()
+	src := "access = flags { read; write; nothing = none; everything = all; }"
+
+	f, err := parser.ParseFile("", src, parser.WithStrictDjinni())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := [...]struct {
+		name        string
+		all, isNone bool
+	}{
+		{"read", false, false},
+		{"write", false, false},
+		{"nothing", false, true},
+		{"everything", true, false},
+	}
+	options := f.TypeDecls[0].Body.(*ast.Enum).Options
+	for i, tt := range tests {
+		o := options[i]
+		if o.Ident.Name != tt.name || o.IsAll != tt.all || o.IsNone != tt.isNone {
+			t.Errorf("incorrect option %d: got %s (all %t, none %t), expected %s (all %t, none %t)",
+				i, o.Ident.Name, o.IsAll, o.IsNone, tt.name, tt.all, tt.isNone)
+		}
+	}
+
+	_, err = parser.ParseFile("", "color = enum { red; every = all; }")
+	if err == nil || err.Error() != "1:29: all is only valid for flags options" {
+		t.Errorf("incorrect error for all in an enum: %v", err)
+	}
+}
+
+func TestEnumOptionComments(t *testing.T) {
+	t.Parallel()
+	src := `
+		color = enum {
+			red; # the color red
+			# the color green
+			green;
+			blue;
+		}
+	`
+
+	f, err := parser.ParseFile("", src, parser.WithComments())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := f.TypeDecls[0].Body.(*ast.Enum).Options
+	tests := [...]struct {
+		name    string
+		doc     string
+		comment string
+	}{
+		{"red", "", "the color red"},
+		{"green", "the color green", ""},
+		{"blue", "", ""},
+	}
+	for i, tt := range tests {
+		o := options[i]
+		if o.Ident.Name != tt.name {
+			t.Fatalf("incorrect option %d: expected %s, got %s", i, tt.name, o.Ident.Name)
+		}
+		if doc := o.Doc.Text(); doc != tt.doc {
+			t.Errorf("%s: incorrect doc: expected %q, got %q", tt.name, tt.doc, doc)
+		}
+		if comment := o.Comment.Text(); comment != tt.comment {
+			t.Errorf("%s: incorrect comment: expected %q, got %q", tt.name, tt.comment, comment)
+		}
+	}
+}
+
+func TestFieldComments(t *testing.T) {
+	t.Parallel()
+	src := `
+		my_record = record {
+			id: i32; # primary key
+			# the display name
+			name: string;
+			tags: set<string> = []; # never null
+			# the score
+			score: f64; # out of 10
+			const max: i32 = 10;
+		}
+	`
+
+	f, err := parser.ParseFile("", src, parser.WithComments())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := f.TypeDecls[0].Body.(*ast.Record).Fields
+	tests := [...]struct {
+		name    string
+		doc     string
+		comment string
+	}{
+		{"id", "", "primary key"},
+		{"name", "the display name", ""},
+		{"tags", "", "never null"},
+		{"score", "the score", "out of 10"},
+	}
+	if len(fields) != len(tests) {
+		t.Fatalf("incorrect number of fields; expected %d, got %d", len(tests), len(fields))
+	}
+	for i, tt := range tests {
+		field := fields[i]
+		if field.Ident.Name != tt.name {
+			t.Fatalf("incorrect field %d: expected %s, got %s", i, tt.name, field.Ident.Name)
+		}
+		if doc := field.Doc.Text(); doc != tt.doc {
+			t.Errorf("%s: incorrect doc: expected %q, got %q", tt.name, tt.doc, doc)
+		}
+		if comment := field.Comment.Text(); comment != tt.comment {
+			t.Errorf("%s: incorrect comment: expected %q, got %q", tt.name, tt.comment, comment)
+		}
+	}
+}
+
+func TestEnumDeriving(t *testing.T) {
+	t.Parallel()
+	src := "my_enum = enum deriving (eq, ord) { first; second; }"
+
+	f, err := parser.ParseFile("", src, parser.WithEnumDeriving())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := f.TypeDecls[0].Body.(*ast.Enum)
+	if e.Deriving != (ast.Deriving{Eq: true, Ord: true}) {
+		t.Errorf("incorrect deriving: %+v", e.Deriving)
+	}
+	if len(e.Options) != 2 {
+		t.Errorf("incorrect number of options; expected 2, got %d", len(e.Options))
+	}
+
+	if _, err := parser.ParseFile("", src); err == nil {
+		t.Error("expected an error without WithEnumDeriving")
+	}
+
+	_, err = parser.ParseFile("", "my_enum = enum deriving (parcelable) {}", parser.WithEnumDeriving())
+	if err == nil || err.Error() != "1:16: enums cannot derive parcelable" {
+		t.Errorf("incorrect error for deriving parcelable: %v", err)
+	}
+}
+
+func TestPositions(t *testing.T) {
+	t.Parallel()
+	src := "my_record = record {\n\tid: i32;\n\tnames: list<string>;\n}\n"
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := f.TypeDecls[0]
+	r := d.Body.(*ast.Record)
+	names := r.Fields[1]
+
+	tests := [...]struct {
+		name string
+		got  token.Pos
+		want token.Pos
+	}{
+		{"TypeDecl", d.Pos, token.Pos{Offset: 0, Line: 1, Column: 1}},
+		{"TypeDeclEnd", d.End, token.Pos{Offset: 54, Line: 4, Column: 2}},
+		{"Record", r.Pos, token.Pos{Offset: 12, Line: 1, Column: 13}},
+		{"FieldIdent", names.Ident.Pos, token.Pos{Offset: 32, Line: 3, Column: 2}},
+		{"FieldIdentEnd", names.Ident.End, token.Pos{Offset: 37, Line: 3, Column: 7}},
+		{"FieldEnd", names.End, token.Pos{Offset: 52, Line: 3, Column: 22}},
+		{"Type", names.Type.Pos, token.Pos{Offset: 39, Line: 3, Column: 9}},
+		{"TypeArg", names.Type.Args[0].Pos, token.Pos{Offset: 44, Line: 3, Column: 14}},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: incorrect position: expected %+v, got %+v", tt.name, tt.want, tt.got)
+		}
+	}
+}
+
+func TestParserReuse(t *testing.T) {
+	t.Parallel()
+	p := parser.New()
+
+	_, err := p.Parse("bad.djinni", []byte("my_record = record { id i32; }"))
+	if err == nil {
+		t.Fatal("expected an error for the first file")
+	}
+
+	f, err := p.Parse("good.djinni", []byte("my_enum = enum { first; }"))
+	if err != nil {
+		t.Fatalf("errors leaked from the previous parse: %v", err)
+	}
+	if len(f.TypeDecls) != 1 || f.TypeDecls[0].Ident.Name != "my_enum" {
+		t.Fatalf("incorrect decls: %+v", f.TypeDecls)
+	}
+
+	f2, err := p.Parse("good.djinni", []byte("my_record = record { id: i32; }"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f2 == f || f.TypeDecls[0].Ident.Name != "my_enum" {
+		t.Error("parsing a second file changed the first result")
+	}
+}
+
+func TestParserReuseAllocs(t *testing.T) {
+	src := []byte(benchSrc)
+	p := parser.New()
+	reused := testing.AllocsPerRun(100, func() {
+		if _, err := p.Parse("", src); err != nil {
+			t.Fatal(err)
+		}
+	})
+	fresh := testing.AllocsPerRun(100, func() {
+		if _, err := parser.ParseFile("", src); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if reused >= fresh {
+		t.Errorf("expected a reused parser to allocate less than ParseFile: got %v, ParseFile %v", reused, fresh)
+	}
+}
+
+const benchSrc = `
+	@import "other.djinni"
+
+	# a record
+	my_record = record {
+		id: i32;
+		names: list<string>;
+		values: map<string, optional<f64>>;
+		const max: i32 = 10;
+	} deriving (eq, ord)
+
+	my_enum = enum {
+		first;
+		second;
+	}
+
+	my_interface = interface +c {
+		static create(): my_interface;
+		get(key: string): optional<my_record>;
+	}
+`
+
+func BenchmarkParseFile(b *testing.B) {
+	src := []byte(benchSrc)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := parser.ParseFile("", src); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParserParse(b *testing.B) {
+	src := []byte(benchSrc)
+	p := parser.New()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Parse("", src); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseLargeFile(b *testing.B) {
+	var sb strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&sb, "# record number %d\n", i)
+		fmt.Fprintf(&sb, "record_%d = record +c +j {\n", i)
+		sb.WriteString("\tid: i64; # the id\n\tname: string;\n\ttags: map<string, list<i32>>;\n")
+		fmt.Fprintf(&sb, "\tconst max: f64 = %d.5e3;\n\tconst label: string = \"record \\\"%d\\\"\";\n", i, i)
+		sb.WriteString("} deriving (eq, ord)\n\n")
+		fmt.Fprintf(&sb, "interface_%d = interface +c +o {\n\tstatic create(id: i64): interface_%d;\n\tget(key: string): optional<record_%d>;\n}\n\n", i, i, i)
+	}
+	src := []byte(sb.String())
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parser.ParseFile("", src); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestFieldNumbers(t *testing.T) {
+	t.Parallel()
+	src := "my_record = record {\n\tid: i32;\n\t@field=1 name: string;\n\tage: i32;\n}"
+
+	f, err := parser.ParseFile("", src, parser.WithFieldNumbers())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := f.TypeDecls[0].Body.(*ast.Record)
+	want := map[string]int{"id": 2, "name": 1, "age": 3}
+	for _, field := range r.Fields {
+		if field.Number != want[field.Ident.Name] {
+			t.Errorf("incorrect number for %s: expected %d, got %d", field.Ident.Name, want[field.Ident.Name], field.Number)
+		}
+	}
+
+	f, err = parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := f.TypeDecls[0].Body.(*ast.Record).Fields[0].Number; n != 0 {
+		t.Errorf("expected no field number without WithFieldNumbers, got %d", n)
+	}
+
+	tests := [...]struct {
+		src string
+		err string
+	}{
+		{"r = record { @field=2 a: i32; @field=2 b: i32; }", "1:31: duplicate field number 2 for b"},
+		{"r = record { @field=0 a: i32; }", `1:14: field number of a must be a positive integer, got "0"`},
+		{"r = record { @field=x a: i32; }", `1:14: field number of a must be a positive integer, got "x"`},
+	}
+	for _, tt := range tests {
+		_, err := parser.ParseFile("", tt.src, parser.WithFieldNumbers())
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%s: expected error %q, got %v", tt.src, tt.err, err)
+		}
+	}
+}
+
+func TestParseDir(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.djinni":       "a = record { id: i32; }",
+		"bad.djinni":     "bad = struct {}",
+		"notes.txt":      "not djinni",
+		"sub/sub.djinni": "sub = enum { first; }",
+	})
+
+	tests := [...]struct {
+		name string
+		opts []parser.Option
+		want []string
+	}{
+		{"flat", nil, []string{"a.djinni", "bad.djinni"}},
+		{"recursive", []parser.Option{parser.WithRecursive()}, []string{"a.djinni", "bad.djinni", "sub/sub.djinni"}},
+	}
+
+	for _, tt := range tests {
+		got, err := parser.ParseDir(dir, tt.opts...)
+
+		var list parser.ErrorList
+		if !errors.As(err, &list) || len(list) != 1 {
+			t.Fatalf("%s: expected a single syntax error, got %v", tt.name, err)
+		}
+		if want := filepath.Join(dir, "bad.djinni"); list[0].Pos.Filename != want {
+			t.Errorf("%s: incorrect error filename: expected %s, got %s", tt.name, want, list[0].Pos.Filename)
+		}
+
+		var names []string
+		for filename := range got {
+			rel, _ := filepath.Rel(dir, filename)
+			names = append(names, filepath.ToSlash(rel))
+		}
+		sort.Strings(names)
+		if diff := cmp.Diff(tt.want, names); diff != "" {
+			t.Errorf("%s: incorrect files: %s", tt.name, diff)
+		}
+	}
+}
+
+func TestDeclFilename(t *testing.T) {
+	t.Parallel()
+
+	src := "a = record {}\nb = enum { first; }\nc = bad {}\nd = interface +c {}\n"
+	f, _ := parser.ParseFile("api.djinni", src)
+	if len(f.TypeDecls) != 4 {
+		t.Fatalf("expected 4 declarations, got %d", len(f.TypeDecls))
+	}
+	for _, decl := range f.TypeDecls {
+		if decl.Filename != "api.djinni" {
+			t.Errorf("%s: incorrect filename %q", decl.Ident.Name, decl.Filename)
+		}
+	}
+}
+
+func TestParseFiles(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.djinni":   "@import \"common.djinni\"\na = record { b: b; }\n",
+		"b.djinni":   "@import \"common.djinni\"\n@extern \"b.yaml\"\nb = enum { first; }\nc = record {}\n",
+		"dup.djinni": "a = enum { first; }\n",
+	})
+	a, b, dup := filepath.Join(dir, "a.djinni"), filepath.Join(dir, "b.djinni"), filepath.Join(dir, "dup.djinni")
+
+	f, err := parser.ParseFiles([]string{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"common.djinni"}, f.Imports); diff != "" {
+		t.Errorf("incorrect imports: %s", diff)
+	}
+	if diff := cmp.Diff([]string{"b.yaml"}, f.Externs); diff != "" {
+		t.Errorf("incorrect externs: %s", diff)
+	}
+	var got []string
+	for _, decl := range f.TypeDecls {
+		got = append(got, decl.Ident.Name+" "+decl.Filename)
+	}
+	want := []string{"a " + a, "b " + b, "c " + b}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("incorrect declarations: %s", diff)
+	}
+
+	sep := string(filepath.Separator)
+	f, err = parser.ParseFiles([]string{a, b, a, dir + sep + "." + sep + "b.djinni"})
+	if err != nil {
+		t.Errorf("expected no error for a file given twice, got %v", err)
+	}
+	if len(f.TypeDecls) != 3 {
+		t.Errorf("incorrect number of decls for a file given twice; expected 3, got %d", len(f.TypeDecls))
+	}
+
+	_, err = parser.ParseFiles([]string{a, dup})
+	if err == nil || err.Error() != dup+":1:1: duplicate type a, also declared at "+a+":2:1" {
+		t.Errorf("incorrect error for a type declared twice: %v", err)
+	}
+
+	if _, err := parser.ParseFiles([]string{a, filepath.Join(dir, "missing.djinni")}); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestPreprocessor(t *testing.T) {
+	t.Parallel()
+	src := "my_record = record { id: ID_TYPE; }"
+	expand := func(src []byte) ([]byte, error) {
+		return bytes.ReplaceAll(src, []byte("ID_TYPE"), []byte("i64")), nil
+	}
+
+	f, err := parser.ParseFile("", src, parser.WithPreprocessor(expand))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ := f.TypeDecls[0].Body.(*ast.Record).Fields[0].Type.String(); typ != "i64" {
+		t.Errorf("incorrect field type: expected i64, got %s", typ)
+	}
+
+	fail := func([]byte) ([]byte, error) { return nil, errors.New("undefined macro") }
+	_, err = parser.ParseFile("defs.djinni", src, parser.WithPreprocessor(fail))
+	var list parser.ErrorList
+	if !errors.As(err, &list) || err.Error() != "defs.djinni: preprocessing failed: undefined macro" {
+		t.Errorf("incorrect error: %v", err)
+	}
+}
+
+func TestDuplicateExtWarning(t *testing.T) {
+	t.Parallel()
+
+	var warnings []string
+	warn := func(pos token.Position, msg string) {
+		warnings = append(warnings, pos.String()+": "+msg)
+	}
+
+	f, err := parser.ParseFile("", "my_record = record +c +c {}", parser.WithWarningHandler(warn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := f.TypeDecls[0].Body.(*ast.Record); r.Ext != (ast.Ext{CPP: true}) {
+		t.Errorf("incorrect ext: %+v", r.Ext)
+	}
+	if diff := cmp.Diff([]string{"1:23: duplicate extension flag +c"}, warnings); diff != "" {
+		t.Errorf("incorrect warnings: %s", diff)
+	}
+}
+
+func TestNestedOptionalWarning(t *testing.T) {
+	t.Parallel()
+
+	var warnings []string
+	warn := func(pos token.Position, msg string) {
+		warnings = append(warnings, pos.String()+": "+msg)
+	}
+
+	src := "my_record = record { a: optional<optional<string>>; b: list<optional<i32>>; }"
+	if _, err := parser.ParseFile("", src, parser.WithWarningHandler(warn)); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"1:25: redundant nested optional in optional<optional<string>>"}
+	if diff := cmp.Diff(want, warnings); diff != "" {
+		t.Errorf("incorrect warnings: %s", diff)
+	}
+}
+
+func TestEmptyFlagsWarning(t *testing.T) {
+	t.Parallel()
+
+	var warnings []string
+	warn := func(pos token.Position, msg string) {
+		warnings = append(warnings, pos.String()+": "+msg)
+	}
+
+	src := `
+		my_flags = flags { every = all; nothing = none; }
+		other_flags = flags { first; every = all; }
+		empty_flags = flags {}
+	`
+	if _, err := parser.ParseFile("", src, parser.WithWarningHandler(warn)); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"2:14: flags has no options besides all and none"}
+	if diff := cmp.Diff(want, warnings); diff != "" {
+		t.Errorf("incorrect warnings: %s", diff)
+	}
+}
+
+func TestRawSource(t *testing.T) {
+	t.Parallel()
+	first := "my_record = record {\n\t# the id\n\tid: i32; # trailing\n} deriving (eq)"
+	second := "my_enum = enum { a; }"
+	src := "# doc\n" + first + "\n\n" + second + "\n"
+
+	f, err := parser.ParseFile("", src, parser.WithRawSource())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(f.TypeDecls[0].RawSource); got != first {
+		t.Errorf("incorrect raw source:\ngot:\n%s\nwant:\n%s", got, first)
+	}
+	if got := string(f.TypeDecls[1].RawSource); got != second {
+		t.Errorf("incorrect raw source:\ngot:\n%s\nwant:\n%s", got, second)
+	}
+
+	f, err = parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw := f.TypeDecls[0].RawSource; raw != nil {
+		t.Errorf("expected no raw source without WithRawSource, got %q", raw)
+	}
+}
+
+func TestStrictDjinni(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name string
+		src  string
+		opts []parser.Option
+		err  string
+	}{
+		{"Annotation", "my_record = @json record {}", nil, "1:13: annotation @json is not standard Djinni"},
+		{"FieldAnnotation", "my_record = record { @field=1 id: i32; }", nil, "1:22: annotation @field is not standard Djinni"},
+		{"EnumValue", "my_enum = enum { a = 1; }", nil, "1:20: enum option values are not standard Djinni"},
+		{"MapConst", `my_record = record { const m: map<string, i32> = {}; }`, nil, "1:50: map constants are not standard Djinni"},
+		{"ListConst", `my_record = record { const l: list<i32> = []; }`, nil, "1:43: list constants are not standard Djinni"},
+		{"InterfaceLanguage", "my_interface = interface { foo(); }", nil, "1:16: interface must declare at least one language: +c, +j or +o"},
+		{"ColonDecl", "my_record : record {}", []parser.Option{parser.WithColonDecls()}, `1:11: expected "=", got ":"`},
+		{"EnumDeriving", "my_enum = enum deriving (eq) {}", []parser.Option{parser.WithEnumDeriving()}, "1:16: deriving is not supported on enums"},
+		{"WasmExt", "i = interface +c +w { f(); }", nil, "1:18: extension flag +w is not standard Djinni"},
+		{"NodeOnly", "i = interface +n { f(); }", []parser.Option{parser.WithAllErrors()}, "1:15: extension flag +n is not standard Djinni (and 1 more errors)"},
+		{"MethodExt", "i = interface +c { f(): i32 +j; }", nil, "1:29: method language flags are not standard Djinni"},
+		{"LeadingDeriving", "r = record deriving (eq) { x: i32; }", nil, "1:12: deriving must follow the closing '}' of the record"},
+	}
+
+	for _, tt := range tests {
+		if _, err := parser.ParseFile("", tt.src, tt.opts...); err != nil {
+			t.Errorf("%s: expected no error without strict mode, got %v", tt.name, err)
+		}
+
+		opts := append(tt.opts, parser.WithStrictDjinni())
+		_, err := parser.ParseFile("", tt.src, opts...)
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%s: incorrect error in strict mode: expected %q, got %v", tt.name, tt.err, err)
+		}
+	}
+
+	// +w and +n do not count as languages of an interface
+	_, err := parser.ParseFile("", "i = interface +n { f(); }", parser.WithStrictDjinni(), parser.WithAllErrors())
+	if list, ok := err.(parser.ErrorList); !ok || len(list) != 2 || list[1].Error() != "1:5: interface must declare at least one language: +c, +j or +o" {
+		t.Errorf("expected +n to be rejected as the only language, got %v", err)
+	}
+
+	src := "my_record = record { id: i32; const c: i32 = -1; } deriving (eq)\nmy_interface = interface +c { const get(): my_record; }"
+	if _, err := parser.ParseFile("", src, parser.WithStrictDjinni()); err != nil {
+		t.Errorf("expected standard Djinni to parse in strict mode, got %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/ast"
+	"github.com/SafetyCulture/djinni-parser/pkg/token"
+)
+
+// Resolve checks that every type referenced by the fields, consts and
+// methods of f is either a Djinni primitive, see token.IsPrimitive, or
+// declared in f or one of its imported files, see ParseFileWithImports.
+// Each unknown type is reported in the returned ErrorList, naming the
+// member that refers to it, e.g. "undefined type strng in my_record.name".
+//
+// Resolve also checks that each constant value referring to another
+// constant, see ast.Ref, names a constant of the same declaration, or, if
+// qualified, an option of the named enum or a constant of the named record
+// or interface.
+//
+// Every type naming an interface is marked as such, see
+// ast.TypeExpr.Interface. An interface is passed by reference, so it can't
+// be a map key or set element, and it can't be a field of a record
+// deriving eq or ord.
+func Resolve(f *ast.IDLFile) error {
+	r := resolver{types: make(map[string]ast.TypeDef)}
+	for _, decl := range f.AllTypeDecls() {
+		r.types[decl.Ident.Name] = decl.Body
+	}
+
+	for _, decl := range f.TypeDecls {
+		r.filename = decl.Filename
+		switch def := decl.Body.(type) {
+		case *ast.Record:
+			for i := range def.Fields {
+				field := &def.Fields[i]
+				r.check(&field.Type, decl.Ident.Name, field.Ident.Name)
+				r.checkValue(field.Default, def, decl.Ident.Name, field.Ident.Name)
+				if (def.Deriving.Eq || def.Deriving.Ord) && hasInterface(field.Type) {
+					r.errors.add(field.Type.Pos.Position(r.filename), fmt.Sprintf("cannot derive eq or ord for %s, field %s refers to an interface", decl.Ident.Name, field.Ident.Name))
+				}
+			}
+			for i := range def.Consts {
+				c := &def.Consts[i]
+				r.check(&c.Type, decl.Ident.Name, c.Ident.Name)
+				r.checkValue(c.Value, def, decl.Ident.Name, c.Ident.Name)
+			}
+		case *ast.Interface:
+			for i := range def.Methods {
+				m := &def.Methods[i]
+				for j := range m.Params {
+					r.check(&m.Params[j].Type, decl.Ident.Name, m.Ident.Name)
+				}
+				if m.Return != nil {
+					r.check(m.Return, decl.Ident.Name, m.Ident.Name)
+				}
+			}
+			for i := range def.Consts {
+				c := &def.Consts[i]
+				r.check(&c.Type, decl.Ident.Name, c.Ident.Name)
+				r.checkValue(c.Value, def, decl.Ident.Name, c.Ident.Name)
+			}
+		}
+	}
+
+	if len(r.errors) > 0 {
+		return r.errors
+	}
+	return nil
+}
+
+type resolver struct {
+	types    map[string]ast.TypeDef // declared types by name
+	filename string                 // file of the declaration being checked
+	errors   ErrorList
+}
+
+// check reports the type, and any of its type arguments, that is neither
+// a primitive nor a declared type. The generic types are always known.
+// Types naming an interface are marked, and reported if they are a map key
+// or set element.
+func (r *resolver) check(t *ast.TypeExpr, decl, member string) {
+	switch name := t.Ident.Name; name {
+	case "_", "map", "set", "list", "optional":
+		// the placeholder of a missing type was reported by the parser
+	default:
+		def, ok := r.types[name]
+		if !ok && !token.IsPrimitive(name) {
+			r.errors.add(t.Pos.Position(r.filename), fmt.Sprintf("undefined type %s in %s.%s", name, decl, member))
+		}
+		_, t.Interface = def.(*ast.Interface)
+	}
+	for i := range t.Args {
+		r.check(&t.Args[i], decl, member)
+	}
+	if (t.Ident.Name == "map" || t.Ident.Name == "set") && len(t.Args) > 0 && t.Args[0].Interface {
+		what := "map key"
+		if t.Ident.Name == "set" {
+			what = "set element"
+		}
+		r.errors.add(t.Args[0].Pos.Position(r.filename), fmt.Sprintf("interface %s cannot be a %s in %s.%s", t.Args[0].Ident.Name, what, decl, member))
+	}
+}
+
+// hasInterface reports whether t, or any of its type arguments, names an
+// interface. The types must have been checked.
+func hasInterface(t ast.TypeExpr) bool {
+	if t.Interface {
+		return true
+	}
+	for _, arg := range t.Args {
+		if hasInterface(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkValue reports the references within the value of a constant of
+// def that don't name an existing constant or enum option.
+func (r *resolver) checkValue(v interface{}, def ast.TypeDef, decl, member string) {
+	switch v := v.(type) {
+	case ast.Ref:
+		if v.Ident.Name == "_" {
+			return // reported by the parser
+		}
+		scope := def
+		if v.Scope != nil {
+			scope = r.types[v.Scope.Name]
+		}
+		if !hasValue(scope, v.Ident.Name) {
+			pos := v.Ident.Pos
+			if v.Scope != nil {
+				pos = v.Scope.Pos
+			}
+			r.errors.add(pos.Position(r.filename), fmt.Sprintf("undefined value %s in %s.%s", v, decl, member))
+		}
+	case ast.RecordLiteral:
+		for _, f := range v.Fields {
+			r.checkValue(f.Value, def, decl, member)
+		}
+	case ast.MapValue:
+		for _, e := range v.Entries {
+			r.checkValue(e.Key, def, decl, member)
+			r.checkValue(e.Value, def, decl, member)
+		}
+	case []interface{}:
+		for _, e := range v {
+			r.checkValue(e, def, decl, member)
+		}
+	}
+}
+
+// hasValue reports whether def has an enum option or constant named name.
+func hasValue(def ast.TypeDef, name string) bool {
+	var consts []ast.Const
+	switch def := def.(type) {
+	case *ast.Enum:
+		for _, o := range def.Options {
+			if o.Ident.Name == name {
+				return true
+			}
+		}
+	case *ast.Record:
+		consts = def.Consts
+	case *ast.Interface:
+		consts = def.Consts
+	}
+	for _, c := range consts {
+		if c.Ident.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,224 @@
+package parser_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/ast"
+	"github.com/SafetyCulture/djinni-parser/pkg/parser"
+)
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+	src := `
+		color = enum { red; }
+		my_record = record {
+			id: i32;
+			name: strng;
+			colors: map<string, list<colour>>;
+			const max: i64 = 1;
+		}
+		my_interface = interface +c {
+			get(c: color, r: optional<my_record>): binary;
+			put(u: unknown): date;
+			const size: sz = 1;
+		}
+	`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = parser.Resolve(f)
+	list, ok := err.(parser.ErrorList)
+	if !ok {
+		t.Fatalf("expected a parser.ErrorList, got %T", err)
+	}
+	want := []string{
+		"5:10: undefined type strng in my_record.name",
+		"6:29: undefined type colour in my_record.colors",
+		"11:11: undefined type unknown in my_interface.put",
+		"12:16: undefined type sz in my_interface.size",
+	}
+	if len(list) != len(want) {
+		t.Fatalf("incorrect number of errors; expected %d, got %d: %v", len(want), len(list), list)
+	}
+	for i, e := range list {
+		if e.Error() != want[i] {
+			t.Errorf("incorrect error %d: got %q, expected %q", i, e, want[i])
+		}
+	}
+}
+
+func TestResolveConstRefs(t *testing.T) {
+	t.Parallel()
+	src := `
+		color = enum { red; green; }
+		limits = interface +c { const max: i32 = 10; }
+		my_record = record {
+			const base: i32 = 1;
+			const doubled: i32 = base;
+			const red: color = color.red;
+			const max: i32 = limits.max;
+			const blue: color = color.blue;
+			const other: i32 = missing;
+			const point: other_record = { x = base, y = limits.min };
+			const colors: list<color> = [color.green, color.pink];
+		}
+	`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = parser.Resolve(f)
+	list, ok := err.(parser.ErrorList)
+	if !ok {
+		t.Fatalf("expected a parser.ErrorList, got %T", err)
+	}
+	want := []string{
+		"9:24: undefined value color.blue in my_record.blue",
+		"10:23: undefined value missing in my_record.other",
+		"11:17: undefined type other_record in my_record.point",
+		"11:48: undefined value limits.min in my_record.point",
+		"12:46: undefined value color.pink in my_record.colors",
+	}
+	if len(list) != len(want) {
+		t.Fatalf("incorrect number of errors; expected %d, got %d: %v", len(want), len(list), list)
+	}
+	for i, e := range list {
+		if e.Error() != want[i] {
+			t.Errorf("incorrect error %d: got %q, expected %q", i, e, want[i])
+		}
+	}
+}
+
+func TestResolvePrimitives(t *testing.T) {
+	t.Parallel()
+	src := `
+		my_record = record {
+			data: binary;
+			created: date;
+			bytes: set<i8>;
+			ratio: optional<f32>;
+		}
+	`
+
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Resolve(f); err != nil {
+		t.Errorf("expected the primitives to resolve, got %v", err)
+	}
+}
+
+func TestResolveFilenames(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.djinni": "a = record {\n\tb: b;\n\tc: strng;\n}",
+		"b.djinni": "b = record {\n\tconst x: i32 = y;\n}",
+	})
+
+	f, err := parser.ParseFiles([]string{filepath.Join(dir, "a.djinni"), filepath.Join(dir, "b.djinni")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, ok := parser.Resolve(f).(parser.ErrorList)
+	if !ok || len(list) != 2 {
+		t.Fatalf("expected 2 errors, got %v", list)
+	}
+	want := []string{
+		filepath.Join(dir, "a.djinni") + ":3:5: undefined type strng in a.c",
+		filepath.Join(dir, "b.djinni") + ":2:17: undefined value y in b.x",
+	}
+	for i, e := range list {
+		if e.Error() != want[i] {
+			t.Errorf("incorrect error %d: got %q, expected %q", i, e, want[i])
+		}
+	}
+}
+
+func TestResolveInterfaceFields(t *testing.T) {
+	t.Parallel()
+	src := `
+		listener = interface +c { changed(); }
+		my_record = record {
+			l: listener;
+			all: list<optional<listener>>;
+			id: i32;
+		}
+	`
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Resolve(f); err != nil {
+		t.Fatalf("expected interface fields to resolve, got %v", err)
+	}
+	fields := f.TypeDecls[1].Body.(*ast.Record).Fields
+	if !fields[0].Type.Interface {
+		t.Error("expected l to refer to an interface")
+	}
+	if all := fields[1].Type; all.Interface || !all.Args[0].Args[0].Interface {
+		t.Errorf("expected only the element type of all to refer to an interface, got %+v", all)
+	}
+	if fields[2].Type.Interface {
+		t.Error("expected id not to refer to an interface")
+	}
+
+	src = `
+		listener = interface +c { changed(); }
+		by_key = record { m: map<listener, i32>; s: set<listener>; }
+		compared = record { l: optional<listener>; } deriving (eq)
+	`
+	f, err = parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, ok := parser.Resolve(f).(parser.ErrorList)
+	if !ok {
+		t.Fatalf("expected a parser.ErrorList, got %v", list)
+	}
+	want := []string{
+		"3:28: interface listener cannot be a map key in by_key.m",
+		"3:51: interface listener cannot be a set element in by_key.s",
+		"4:26: cannot derive eq or ord for compared, field l refers to an interface",
+	}
+	if len(list) != len(want) {
+		t.Fatalf("incorrect number of errors; expected %d, got %d: %v", len(want), len(list), list)
+	}
+	for i, e := range list {
+		if e.Error() != want[i] {
+			t.Errorf("incorrect error %d: got %q, expected %q", i, e, want[i])
+		}
+	}
+}
+
+func TestResolveImported(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.djinni":   "@import \"common.djinni\"\nmain = record { c: common; }",
+		"common.djinni": "common = enum { first; }",
+	})
+
+	f, err := parser.ParseFileWithImports(filepath.Join(dir, "main.djinni"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Resolve(f); err != nil {
+		t.Errorf("expected the imported type to resolve, got %v", err)
+	}
+
+	f, err = parser.ParseFile(filepath.Join(dir, "main.djinni"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Resolve(f); err == nil {
+		t.Error("expected an error without the parsed imports")
+	}
+}
@@ -0,0 +1,304 @@
+// Package printer implements printing of AST nodes as Djinni IDL source.
+//
+package printer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/ast"
+	"github.com/SafetyCulture/djinni-parser/pkg/scanner"
+)
+
+// indent is the indentation of the members of a type definition.
+const indent = "    "
+
+type printer struct {
+	bytes.Buffer
+}
+
+// Fprint "pretty-prints" the file f to w in canonical form: members are
+// indented by four spaces, extension flags are ordered +c +j +o and type
+// expressions are printed as by ast.TypeExpr.String.
+//
+// Doc comments and the line comments of enum options are printed with the
+// nodes they belong to; other comments are not printed. A file containing
+// an ast.BadDef cannot be printed.
+func Fprint(w io.Writer, f *ast.IDLFile) error {
+	var p printer
+	if err := p.file(f); err != nil {
+		return err
+	}
+	_, err := w.Write(p.Bytes())
+	return err
+}
+
+func (p *printer) file(f *ast.IDLFile) error {
+	sep := ""
+	if len(f.Imports) > 0 || len(f.Externs) > 0 {
+		for _, imp := range f.Imports {
+			fmt.Fprintf(p, "@import %q\n", imp)
+		}
+		for _, ext := range f.Externs {
+			fmt.Fprintf(p, "@extern %q\n", ext)
+		}
+		sep = "\n"
+	}
+	if len(f.Namespace) > 0 {
+		fmt.Fprintf(p, "%s@namespace %s;\n", sep, strings.Join(f.Namespace, "."))
+		sep = "\n"
+	}
+	for i := range f.TypeDecls {
+		p.WriteString(sep)
+		if err := p.typeDecl(&f.TypeDecls[i]); err != nil {
+			return err
+		}
+		sep = "\n"
+	}
+	return nil
+}
+
+func (p *printer) doc(g *ast.CommentGroup, prefix string) {
+	if g == nil {
+		return
+	}
+	for _, c := range g.List {
+		p.WriteString(prefix + c.Text + "\n")
+	}
+}
+
+// lineComment prints g, if any, after the preceding token on the same line.
+func (p *printer) lineComment(g *ast.CommentGroup) {
+	if g == nil {
+		return
+	}
+	for _, c := range g.List {
+		p.WriteString(" " + c.Text)
+	}
+}
+
+func (p *printer) annotations(list []ast.Annotation) {
+	for _, a := range list {
+		p.WriteString("@" + a.Name)
+		if a.Value != "" {
+			p.WriteString("=" + a.Value)
+		}
+		p.WriteByte(' ')
+	}
+}
+
+func (p *printer) ext(ext ast.Ext) {
+	if ext.CPP {
+		p.WriteString(" +c")
+	}
+	if ext.Java {
+		p.WriteString(" +j")
+	}
+	if ext.ObjC {
+		p.WriteString(" +o")
+	}
+	if ext.Wasm {
+		p.WriteString(" +w")
+	}
+	if ext.Node {
+		p.WriteString(" +n")
+	}
+}
+
+func (p *printer) deriving(d ast.Deriving) {
+	var traits []string
+	if d.Eq {
+		traits = append(traits, "eq")
+	}
+	if d.Ord {
+		traits = append(traits, "ord")
+	}
+	if d.Parcelable {
+		traits = append(traits, "parcelable")
+	}
+	if len(traits) > 0 {
+		p.WriteString(" deriving (" + strings.Join(traits, ", ") + ")")
+	}
+}
+
+func (p *printer) typeDecl(d *ast.TypeDecl) error {
+	p.doc(d.Doc, "")
+	p.WriteString(d.Ident.Name + " = ")
+	p.annotations(d.Annotations)
+
+	switch def := d.Body.(type) {
+	case *ast.Enum:
+		if def.Flags {
+			p.WriteString("flags")
+		} else {
+			p.WriteString("enum")
+		}
+		p.deriving(def.Deriving)
+		p.WriteString(" {\n")
+		for _, o := range def.Options {
+			p.doc(o.Doc, indent)
+			p.WriteString(indent + o.Ident.Name)
+			switch {
+			case o.Value != nil:
+				p.WriteString(" = ")
+				p.literal(o.RawValue, int64(*o.Value))
+			case o.IsAll:
+				p.WriteString(" = all")
+			case o.IsNone:
+				p.WriteString(" = none")
+			}
+			p.WriteString(";")
+			p.lineComment(o.Comment)
+			p.WriteString("\n")
+		}
+		p.WriteString("}\n")
+
+	case *ast.Record:
+		p.WriteString("record")
+		p.ext(def.Ext)
+		p.WriteString(" {\n")
+		for _, f := range def.Fields {
+			p.doc(f.Doc, indent)
+			p.WriteString(indent)
+			p.annotations(f.Annotations)
+			p.WriteString(f.Ident.Name + ": " + f.Type.String())
+			if f.Default != nil {
+				p.WriteString(" = ")
+				p.literal(f.DefaultRaw, f.Default)
+			}
+			p.WriteString(";")
+			p.lineComment(f.Comment)
+			p.WriteString("\n")
+		}
+		for i := range def.Consts {
+			p.constDecl(&def.Consts[i])
+		}
+		p.WriteString("}")
+		p.deriving(def.Deriving)
+		p.WriteString("\n")
+
+	case *ast.Interface:
+		p.WriteString("interface")
+		p.ext(def.Ext)
+		p.WriteString(" {\n")
+		for i := range def.Methods {
+			p.method(&def.Methods[i])
+		}
+		for i := range def.Consts {
+			p.constDecl(&def.Consts[i])
+		}
+		p.WriteString("}\n")
+
+	default:
+		return fmt.Errorf("printer: cannot print %s: unexpected definition %T", d.Ident.Name, d.Body)
+	}
+	return nil
+}
+
+func (p *printer) method(m *ast.Method) {
+	p.doc(m.Doc, indent)
+	p.WriteString(indent)
+	if m.Static {
+		p.WriteString("static ")
+	}
+	if m.Const {
+		p.WriteString("const ")
+	}
+	p.WriteString(m.Ident.Name + "(")
+	for i, param := range m.Params {
+		if i > 0 {
+			p.WriteString(", ")
+		}
+		p.WriteString(param.Ident.Name + ": " + param.Type.String())
+	}
+	p.WriteString(")")
+	if m.Return != nil {
+		p.WriteString(": " + m.Return.String())
+	}
+	p.ext(m.Ext)
+	p.WriteString(";\n")
+}
+
+func (p *printer) constDecl(c *ast.Const) {
+	p.doc(c.Doc, indent)
+	p.WriteString(indent + "const " + c.Ident.Name + ": " + c.Type.String() + " = ")
+	p.literal(c.Raw, c.Value)
+	p.WriteString(";\n")
+}
+
+// literal prints the number or string v as it was spelled in the source,
+// e.g. 0xFF rather than 255, if raw is its source text. Other values, and
+// values that no longer match their source text, are printed by value.
+func (p *printer) literal(raw string, v interface{}) {
+	var same bool
+	switch v := v.(type) {
+	case int64:
+		n, err := scanner.IntValue(raw)
+		same = err == nil && n == v
+	case float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		same = err == nil && f == v
+	case string:
+		s, err := strconv.Unquote(raw)
+		same = err == nil && s == v
+	}
+	if same {
+		p.WriteString(raw)
+		return
+	}
+	p.value(v)
+}
+
+func (p *printer) value(v interface{}) {
+	switch v := v.(type) {
+	case int64:
+		p.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		s := strconv.FormatFloat(v, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0" // keep the value a float when reparsed
+		}
+		p.WriteString(s)
+	case string:
+		p.WriteString(strconv.Quote(v))
+	case bool:
+		p.WriteString(strconv.FormatBool(v))
+	case ast.NullValue:
+		p.WriteString("null")
+	case ast.Ref:
+		p.WriteString(v.String())
+	case ast.RecordLiteral:
+		p.WriteString("{")
+		for i, f := range v.Fields {
+			if i > 0 {
+				p.WriteString(", ")
+			}
+			p.WriteString(f.Ident.Name + " = ")
+			p.value(f.Value)
+		}
+		p.WriteString("}")
+	case []interface{}:
+		p.WriteString("[")
+		for i, e := range v {
+			if i > 0 {
+				p.WriteString(", ")
+			}
+			p.value(e)
+		}
+		p.WriteString("]")
+	case ast.MapValue:
+		p.WriteString("{")
+		for i, e := range v.Entries {
+			if i > 0 {
+				p.WriteString(", ")
+			}
+			p.value(e.Key)
+			p.WriteString(": ")
+			p.value(e.Value)
+		}
+		p.WriteString("}")
+	}
+}
@@ -0,0 +1,131 @@
+package printer_test
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/ast"
+	"github.com/SafetyCulture/djinni-parser/pkg/parser"
+	"github.com/SafetyCulture/djinni-parser/pkg/printer"
+	"github.com/SafetyCulture/djinni-parser/pkg/token"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// ignorePos ignores node positions when comparing parsed trees.
+var ignorePos = cmpopts.IgnoreTypes(token.Pos{})
+
+var opts = []parser.Option{
+	parser.WithComments(),
+	parser.WithNamespaces(),
+	parser.WithEnumDeriving(),
+}
+
+// TestFprint prints each file in testdata and compares the output to the
+// matching .golden file. The output must parse to the original tree.
+func TestFprint(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.input"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, filename := range files {
+		filename := filename
+		t.Run(filepath.Base(filename), func(t *testing.T) {
+			f, err := parser.ParseFile(filename, nil, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			if err := printer.Fprint(&buf, f); err != nil {
+				t.Fatal(err)
+			}
+			got := buf.Bytes()
+
+			golden := strings.TrimSuffix(filename, ".input") + ".golden"
+			if *update {
+				if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("incorrect output:\ngot:\n%s\nwant:\n%s", got, want)
+			}
+
+			reparsed, err := parser.ParseFile(golden, got, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ignoreSource := cmp.Options{
+				cmpopts.IgnoreFields(ast.TypeDecl{}, "Filename"),
+				cmpopts.IgnoreFields(ast.Const{}, "Raw"),
+				cmpopts.IgnoreFields(ast.Field{}, "DefaultRaw"),
+			}
+			if diff := cmp.Diff(f, reparsed, ignorePos, ignoreSource); diff != "" {
+				t.Errorf("reparsed output differs from the original:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFprintBadDef(t *testing.T) {
+	t.Parallel()
+	f := &ast.IDLFile{TypeDecls: []ast.TypeDecl{{Ident: ast.Ident{Name: "bad"}, Body: &ast.BadDef{}}}}
+
+	if err := printer.Fprint(ioutil.Discard, f); err == nil {
+		t.Error("expected an error for a bad definition")
+	}
+}
+
+func TestFprintEnumRawValue(t *testing.T) {
+	t.Parallel()
+	src := "my_flags = enum {\n    first = 0x01;\n    second = 0b10;\n    third = 3;\n}\n"
+	f, err := parser.ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw := f.TypeDecls[0].Body.(*ast.Enum).Options[0].RawValue; raw != "0x01" {
+		t.Errorf("incorrect raw value: expected 0x01, got %q", raw)
+	}
+
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, f); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != src {
+		t.Errorf("incorrect output:\ngot:\n%s\nwant:\n%s", got, src)
+	}
+}
+
+func TestFprintStaleRaw(t *testing.T) {
+	t.Parallel()
+	f, err := parser.ParseFile("", "my_record = record { const mask: i32 = 0xFF; const name: string = \"a\"; }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	consts := f.TypeDecls[0].Body.(*ast.Record).Consts
+	consts[0].Value = int64(256)
+	consts[1].Value = "b"
+
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, f); err != nil {
+		t.Fatal(err)
+	}
+	want := "my_record = record {\n    const mask: i32 = 256;\n    const name: string = \"b\";\n}\n"
+	if got := buf.String(); got != want {
+		t.Errorf("incorrect output:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,25 @@
+package scanner
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/token"
+)
+
+func TestInternLimit(t *testing.T) {
+	t.Parallel()
+	var s Scanner
+	for i := 0; i < 100; i++ {
+		var src []byte
+		for j := 0; j < 200; j++ {
+			src = fmt.Appendf(src, "ident_%d_%d ", i, j)
+		}
+		s.Init(src, nil, 0)
+		for tok, _ := s.Scan(); tok != token.EOF; tok, _ = s.Scan() {
+		}
+		if len(s.idents) > maxIdents+200 {
+			t.Fatalf("file %d: the identifiers of earlier files were kept: %d", i, len(s.idents))
+		}
+	}
+}
@@ -3,27 +3,84 @@
 package scanner
 
 import (
+	"fmt"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+
 	"github.com/SafetyCulture/djinni-parser/pkg/token"
 )
 
+// An ErrorHandler may be provided to Scanner.Init. If a syntax error is
+// encountered and a handler was installed, the handler is called with a
+// position and an error message. The position points to the beginning of
+// the offending token.
+type ErrorHandler func(pos token.Position, msg string)
+
+// A Mode value is a set of flags (or 0). They control scanner behavior.
+type Mode uint
+
+const (
+	// ScanTrivia returns runs of whitespace, including newlines, as
+	// WHITESPACE tokens instead of skipping them. Together with the
+	// comments, the token stream then covers every byte of the source.
+	ScanTrivia Mode = 1 << iota
+)
+
 // Scanner is a lexical scanner for the Djinni IDL.
 type Scanner struct {
-	src []byte
+	src  []byte
+	err  ErrorHandler // error reporting; or nil
+	mode Mode         // scanning mode
 
 	// scanning state
-	ch       rune // current character
-	offset   int  // character offset
-	rdOffset int  // reading offset (position after current character)
+	ch         rune           // current character
+	offset     int            // character offset
+	rdOffset   int            // reading offset (position after current character)
+	line       int            // current line
+	lineOffset int            // current line offset
+	tokPos     token.Position // position of the most recently scanned token
+
+	idents map[string]string // identifiers scanned so far, to share their literals
+
+	// public state - ok to modify
+	ErrorCount int // number of errors encountered
 }
 
 const bom = 0xFEFF // byte order mark, only permitted as very first character
 
-// Init initiates a Scanner
-func (s *Scanner) Init(src []byte) {
+// maxIdents is the number of identifiers above which Init forgets the
+// identifiers of the earlier sources, so that a long-lived Scanner doesn't
+// keep every identifier it has ever seen.
+const maxIdents = 4096
+
+// Init prepares the scanner s to tokenize src in the given mode, by
+// setting the scanner at the beginning of src. A Scanner can be reused
+// for another source by calling Init again, in which case the literals of
+// the identifiers seen so far are shared with the new source, up to a
+// limit. The zero Scanner must be initialized with Init before use.
+//
+// Calls to Scan will invoke the error handler err if they encounter a
+// syntax error, such as an illegal character or an unterminated string,
+// and err is not nil. For each error the ErrorCount is incremented as
+// well. The offending text is still returned as a token, usually ILLEGAL,
+// so that the scanner never skips source silently.
+func (s *Scanner) Init(src []byte, err ErrorHandler, mode Mode) {
 	s.src = src
+	s.err = err
+	s.mode = mode
 	s.ch = ' '
 	s.offset = 0
 	s.rdOffset = 0
+	s.line = 1
+	s.lineOffset = 0
+	s.tokPos = token.Position{}
+	if len(s.idents) > maxIdents {
+		for ident := range s.idents {
+			delete(s.idents, ident)
+		}
+	}
+	s.ErrorCount = 0
 
 	s.next()
 	if s.ch == bom {
@@ -31,43 +88,146 @@ func (s *Scanner) Init(src []byte) {
 	}
 }
 
-// read the next Unicode from the source
-// < 0 means end-of-file.
+// Read the next Unicode char into s.ch.
+// s.ch < 0 means end-of-file. An invalid UTF-8 encoding is read as
+// utf8.RuneError, one byte at a time.
 func (s *Scanner) next() {
+	// \r\n is a single newline, counted at the \n
+	if isNewline(s.ch) && !(s.ch == '\r' && s.peek() == '\n') {
+		s.line++
+		s.lineOffset = s.rdOffset
+	}
 	if s.rdOffset < len(s.src) {
 		s.offset = s.rdOffset
-		s.ch = rune(s.src[s.rdOffset])
-		s.rdOffset += 1
+		r, w := rune(s.src[s.rdOffset]), 1
+		switch {
+		case r == 0:
+			s.error(s.position(s.offset), "illegal character NUL")
+		case r >= utf8.RuneSelf:
+			// not ASCII
+			r, w = utf8.DecodeRune(s.src[s.rdOffset:])
+			if r == utf8.RuneError && w == 1 {
+				s.error(s.position(s.offset), "illegal UTF-8 encoding")
+			} else if r == bom && s.offset > 0 {
+				s.error(s.position(s.offset), "illegal byte order mark")
+			}
+		}
+		s.rdOffset += w
+		s.ch = r
 	} else {
 		s.offset = len(s.src)
 		s.ch = -1 // eof
 	}
 }
 
+// isNewline reports whether ch ends a line. Besides \n, a lone \r and
+// the \r of \r\n end lines, so that files with Windows or classic Mac
+// line endings scan the same.
+func isNewline(ch rune) bool {
+	return ch == '\n' || ch == '\r'
+}
+
+// position returns the position of offs, which must be on the current line.
+func (s *Scanner) position(offs int) token.Position {
+	return token.Position{Offset: offs, Line: s.line, Column: offs - s.lineOffset + 1}
+}
+
+func (s *Scanner) error(pos token.Position, msg string) {
+	if s.err != nil {
+		s.err(pos, msg)
+	}
+	s.ErrorCount++
+}
+
+func isSpace(ch rune) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
+
+// peek returns the byte following the most recently read character without
+// advancing the scanner. If the scanner is at EOF, peek returns 0.
+func (s *Scanner) peek() byte {
+	if s.rdOffset < len(s.src) {
+		return s.src[s.rdOffset]
+	}
+	return 0
+}
+
 func (s *Scanner) skipWhitespace() {
-	for s.ch == ' ' || s.ch == '\t' || s.ch == '\n' || s.ch == '\r' {
+	for isSpace(s.ch) {
 		s.next()
 	}
 }
 
-// Scan will scan the next rune and consume any literals
-func (s *Scanner) Scan() (tok token.Token, lit string) {
+func (s *Scanner) scanWhitespace() string {
+	offs := s.offset
 	s.skipWhitespace()
+	return string(s.src[offs:s.offset])
+}
+
+// Line returns the line number, starting at 1, of the most recently
+// scanned token.
+func (s *Scanner) Line() int {
+	return s.tokPos.Line
+}
+
+// Position returns the position of the most recently scanned token.
+// The Filename is left empty, as the scanner only sees the source.
+func (s *Scanner) Position() token.Position {
+	return s.tokPos
+}
+
+// Scan scans the next token and returns the token and its literal string.
+// The source end is indicated by token.EOF; Scan keeps returning EOF once
+// it is reached.
+//
+// The literal is the source text of identifiers, numbers, strings,
+// annotations and comments; for other tokens it is empty. A character that
+// doesn't start a valid token is returned as an ILLEGAL token whose literal
+// is the offending text; it is never skipped silently.
+//
+// A # comment is returned as a single COMMENT token whose literal is the
+// text from the '#' up to, but excluding, the end of the line. A trailing
+// comment shares its Line with the token before it, whereas a comment
+// that starts its own line (such as a doc comment) does not.
+func (s *Scanner) Scan() (tok token.Token, lit string) {
+	if s.mode&ScanTrivia == 0 {
+		s.skipWhitespace()
+	}
+	s.tokPos = s.position(s.offset)
 
 	switch ch := s.ch; {
+	case isSpace(ch):
+		tok = token.WHITESPACE
+		lit = s.scanWhitespace()
 	case isLetter(ch):
-		lit = s.scanIdentifier()
-		tok = token.Lookup(lit)
-	case isDigit(ch):
+		ident := s.scanIdentifier()
+		tok = token.Lookup(string(ident))
+		if tok == token.IDENT {
+			lit = s.intern(ident)
+		} else {
+			lit = tok.String() // no copy for keywords
+		}
+	case isDecimal(ch) || ch == '.' && isDecimal(rune(s.peek())):
 		tok, lit = s.scanNumber()
 	default:
 		s.next() // always make progress
 		switch ch {
 		case '@':
 			ident := s.scanIdentifier()
-			if ident == "import" {
+			switch string(ident) {
+			case "import":
 				tok = token.IMPORT
-				lit = "@import"
+				lit = tok.String()
+			case "extern":
+				tok = token.EXTERN
+				lit = tok.String()
+			case "":
+				s.error(s.tokPos, "expected annotation name after '@'")
+				tok = token.ILLEGAL
+				lit = "@"
+			default:
+				tok = token.ANNOTATION
+				lit = string(s.src[s.tokPos.Offset:s.offset])
 			}
 		case '"':
 			tok = token.STRING
@@ -77,6 +237,8 @@ func (s *Scanner) Scan() (tok token.Token, lit string) {
 			lit = s.scanComment()
 		case '=':
 			tok = token.ASSIGN
+		case '-':
+			tok = token.MINUS
 		case '(':
 			tok = token.LPAREN
 		case ')':
@@ -85,6 +247,10 @@ func (s *Scanner) Scan() (tok token.Token, lit string) {
 			tok = token.LBRACE
 		case '}':
 			tok = token.RBRACE
+		case '[':
+			tok = token.LBRACK
+		case ']':
+			tok = token.RBRACK
 		case '<':
 			tok = token.LANGLE
 		case '>':
@@ -95,71 +261,229 @@ func (s *Scanner) Scan() (tok token.Token, lit string) {
 			tok = token.SEMICOLON
 		case ':':
 			tok = token.COLON
+		case '.':
+			tok = token.PERIOD
 		case '+':
 			tok = s.scanLangFlag()
+			if tok == token.ILLEGAL {
+				s.error(s.tokPos, "expected language flag after '+'")
+				lit = "+"
+			}
 		case -1:
 			tok = token.EOF
 		default:
+			if ch != bom && ch != utf8.RuneError && ch != 0 {
+				// already reported by next
+				s.error(s.tokPos, fmt.Sprintf("illegal character %#U", ch))
+			}
 			tok = token.ILLEGAL
-			lit = string(ch)
+			lit = string(s.src[s.tokPos.Offset:s.offset])
 		}
 	}
 
 	return
 }
 
+// ScanWithPos is like Scan, but also returns the position of the token.
+func (s *Scanner) ScanWithPos() (pos token.Position, tok token.Token, lit string) {
+	tok, lit = s.Scan()
+	return s.tokPos, tok, lit
+}
+
+// TokenInfo describes a single token returned by Tokenize.
+type TokenInfo struct {
+	Pos token.Position // position of the token
+	Tok token.Token    // the token
+	Lit string         // literal string of the token, as returned by Scan
+}
+
+// Tokenize scans src and returns all of its tokens, including comments but
+// excluding the final EOF.
+func Tokenize(src []byte) []TokenInfo {
+	var s Scanner
+	s.Init(src, nil, 0)
+	var tokens []TokenInfo
+	for {
+		pos, tok, lit := s.ScanWithPos()
+		if tok == token.EOF {
+			return tokens
+		}
+		tokens = append(tokens, TokenInfo{pos, tok, lit})
+	}
+}
+
+// intern returns the identifier as a string, copying it from the source
+// only the first time it is seen. Type names such as i32 or string recur
+// throughout a file.
+func (s *Scanner) intern(ident []byte) string {
+	if lit, ok := s.idents[string(ident)]; ok {
+		return lit
+	}
+	if s.idents == nil {
+		s.idents = make(map[string]string)
+	}
+	lit := string(ident)
+	s.idents[lit] = lit
+	return lit
+}
+
+// isLetter reports whether ch may start an identifier: an ASCII or
+// Unicode letter, or '_'.
 func isLetter(ch rune) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+	return 'a' <= lower(ch) && lower(ch) <= 'z' || ch == '_' || ch >= utf8.RuneSelf && unicode.IsLetter(ch)
 }
 
+// isDigit reports whether ch is an ASCII or Unicode digit, which may
+// follow the first letter of an identifier.
 func isDigit(ch rune) bool {
+	return isDecimal(ch) || ch >= utf8.RuneSelf && unicode.IsDigit(ch)
+}
+
+// isDecimal reports whether ch is an ASCII digit, as used in numbers.
+func isDecimal(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
-func (s *Scanner) scanIdentifier() string {
+// scanIdentifier returns the identifier as a subslice of the source, so
+// that keywords are looked up without copying.
+func (s *Scanner) scanIdentifier() []byte {
 	offs := s.offset
 	for isLetter(s.ch) || isDigit(s.ch) {
 		s.next()
 	}
-	return string(s.src[offs:s.offset])
+	return s.src[offs:s.offset]
 }
 
+// scanNumber scans a decimal integer or float, or an integer with a 0x
+// (hexadecimal), 0b (binary) or 0o (octal) prefix. A float may start with
+// the decimal point, as in .5, and have an exponent, as in 1.5e10 or 2E-3.
+// The literal is returned as written.
 func (s *Scanner) scanNumber() (token.Token, string) {
 	offs := s.offset
 	tok := token.INT
+	if s.ch == '0' {
+		s.next()
+		base := 0
+		switch lower(s.ch) {
+		case 'x':
+			base = 16
+		case 'b':
+			base = 2
+		case 'o':
+			base = 8
+		}
+		if base != 0 {
+			s.next()
+			digits := s.offset
+			for digitVal(s.ch) < base {
+				s.next()
+			}
+			if s.offset == digits {
+				s.error(s.tokPos, litName(base)+" literal has no digits")
+			}
+			return tok, string(s.src[offs:s.offset])
+		}
+	}
 	s.scanMantissa()
 	if s.ch == '.' {
 		tok = token.FLOAT
 		s.next()
 		s.scanMantissa()
 	}
+	if lower(s.ch) == 'e' {
+		tok = token.FLOAT
+		s.next()
+		if s.ch == '-' || s.ch == '+' {
+			s.next()
+		}
+		s.scanMantissa()
+	}
 	return tok, string(s.src[offs:s.offset])
 }
 
+func litName(base int) string {
+	switch base {
+	case 2:
+		return "binary"
+	case 8:
+		return "octal"
+	}
+	return "hexadecimal"
+}
+
+// IntValue returns the value of lit, an INT literal as returned by Scan
+// that may be preceded by '-'. An integer without a 0x, 0b or 0o prefix is
+// decimal even if it has leading zeros, so 010 is 10.
+func IntValue(lit string) (int64, error) {
+	sign, digits := "", lit
+	if len(digits) > 0 && digits[0] == '-' {
+		sign, digits = "-", digits[1:]
+	}
+	base := 10
+	if len(digits) > 1 && digits[0] == '0' {
+		switch lower(rune(digits[1])) {
+		case 'x':
+			base = 16
+		case 'b':
+			base = 2
+		case 'o':
+			base = 8
+		}
+		if base != 10 {
+			digits = digits[2:]
+		}
+	}
+	return strconv.ParseInt(sign+digits, base, 64)
+}
+
+func lower(ch rune) rune { return ('a' - 'A') | ch }
+
+func digitVal(ch rune) int {
+	switch {
+	case '0' <= ch && ch <= '9':
+		return int(ch - '0')
+	case 'a' <= lower(ch) && lower(ch) <= 'f':
+		return int(lower(ch) - 'a' + 10)
+	}
+	return 16 // larger than any legal digit val
+}
+
 func (s *Scanner) scanMantissa() {
-	for isDigit(s.ch) {
+	for isDecimal(s.ch) {
 		s.next()
 	}
 }
 
+// scanString scans a string literal. A backslash escapes the following
+// character, so that \" doesn't terminate the string; the literal is
+// returned as written, including the quotes and escapes. A string that
+// isn't closed on its line is reported, and its literal ends there.
 func (s *Scanner) scanString() string {
 	offs := s.offset - 1 // '"' opening already consumed
 	for {
 		ch := s.ch
-		if ch == '\n' || ch < 0 {
+		if isNewline(ch) || ch < 0 {
+			s.error(s.tokPos, "unterminated string literal")
 			break
 		}
 		s.next()
 		if ch == '"' {
 			break
 		}
+		if ch == '\\' && !isNewline(s.ch) && s.ch >= 0 {
+			s.next()
+		}
 	}
 	return string(s.src[offs:s.offset])
 }
 
+// scanComment scans a # comment through to the end of the line. The literal
+// includes the leading '#' but not the terminating newline, be it \n, \r\n
+// or \r. A comment ends at the end of the source too, so it can't be left
+// unterminated.
 func (s *Scanner) scanComment() string {
 	offs := s.offset - 1 // '#' already consumed
-	for s.ch != '\n' && s.ch >= 0 {
+	for !isNewline(s.ch) && s.ch >= 0 {
 		s.next()
 	}
 	return string(s.src[offs:s.offset])
@@ -176,6 +500,12 @@ func (s *Scanner) scanLangFlag() (tok token.Token) {
 	case 'j':
 		tok = token.JAVA
 		s.next()
+	case 'w':
+		tok = token.WASM
+		s.next()
+	case 'n':
+		tok = token.NODE
+		s.next()
 	}
 	return
 }
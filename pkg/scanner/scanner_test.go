@@ -1,6 +1,8 @@
 package scanner_test
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/SafetyCulture/djinni-parser/pkg/scanner"
@@ -18,23 +20,30 @@ var tokens = [...]el{
 	{token.IDENT, "foobar"},
 	{token.IDENT, "_foo"},
 	{token.IDENT, "bar1234"},
+	{token.IDENT, "caf\u00e9"},
+	{token.IDENT, "\u65e5\u672c\u0661"},
 
 	{token.INT, "123456"},
 	{token.FLOAT, "1234.56"},
 	{token.STRING, `"foobar"`},
+	{token.ANNOTATION, "@json"},
 
 	{token.ASSIGN, "="},
+	{token.MINUS, "-"},
 
 	{token.LPAREN, "("},
 	{token.LBRACE, "{"},
 	{token.RPAREN, ")"},
 	{token.RBRACE, "}"},
+	{token.LBRACK, "["},
+	{token.RBRACK, "]"},
 	{token.LANGLE, "<"},
 	{token.RANGLE, ">"},
 
 	{token.COMMA, ","},
 	{token.SEMICOLON, ";"},
 	{token.COLON, ":"},
+	{token.PERIOD, "."},
 
 	{token.ENUM, "enum"},
 	{token.FLAGS, "flags"},
@@ -44,6 +53,7 @@ var tokens = [...]el{
 	{token.MAP, "map"},
 	{token.SET, "set"},
 	{token.LIST, "list"},
+	{token.OPTIONAL, "optional"},
 
 	{token.DERIVING, "deriving"},
 	{token.EQUALITY, "eq"},
@@ -53,10 +63,13 @@ var tokens = [...]el{
 	{token.STATIC, "static"},
 	{token.CONST, "const"},
 	{token.IMPORT, "@import"},
+	{token.EXTERN, "@extern"},
 
 	{token.CPP, "+c"},
 	{token.OBJC, "+o"},
 	{token.JAVA, "+j"},
+	{token.WASM, "+w"},
+	{token.NODE, "+n"},
 }
 
 const whitespace = "  \t  \n\n\n" // to separate tokens
@@ -73,7 +86,7 @@ var source = func() []byte {
 func TestScan(t *testing.T) {
 
 	var s scanner.Scanner
-	s.Init(source())
+	s.Init(source(), nil, 0)
 
 	for _, e := range tokens {
 		tok, lit := s.Scan()
@@ -84,3 +97,357 @@ func TestScan(t *testing.T) {
 		}
 	}
 }
+
+func TestScanComments(t *testing.T) {
+	tests := [...]struct {
+		name  string
+		src   string
+		want  []el
+		lines []int
+	}{
+		{
+			"EOFWithoutNewline",
+			"# a comment",
+			[]el{{token.COMMENT, "# a comment"}, {token.EOF, ""}},
+			[]int{1, 1},
+		},
+		{
+			"HashInString",
+			`"not # a comment"`,
+			[]el{{token.STRING, `"not # a comment"`}, {token.EOF, ""}},
+			[]int{1, 1},
+		},
+		{
+			"DocAndTrailing",
+			"# doc\nid; # trailing\n",
+			[]el{{token.COMMENT, "# doc"}, {token.IDENT, "id"}, {token.SEMICOLON, ""}, {token.COMMENT, "# trailing"}, {token.EOF, ""}},
+			[]int{1, 2, 2, 2, 3},
+		},
+		{
+			"CRLF",
+			"# doc\r\nid; # trailing\r\n",
+			[]el{{token.COMMENT, "# doc"}, {token.IDENT, "id"}, {token.SEMICOLON, ""}, {token.COMMENT, "# trailing"}, {token.EOF, ""}},
+			[]int{1, 2, 2, 2, 3},
+		},
+		{
+			"BareCR",
+			"# doc\rid; # trailing\r",
+			[]el{{token.COMMENT, "# doc"}, {token.IDENT, "id"}, {token.SEMICOLON, ""}, {token.COMMENT, "# trailing"}, {token.EOF, ""}},
+			[]int{1, 2, 2, 2, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		var s scanner.Scanner
+		s.Init([]byte(tt.src), nil, 0)
+
+		for i, e := range tt.want {
+			tok, lit := s.Scan()
+			if tok != e.tok || lit != e.lit {
+				t.Errorf("%s: bad token %d: got %s %q, expected %s %q", tt.name, i, tok, lit, e.tok, e.lit)
+			}
+			if line := s.Line(); line != tt.lines[i] {
+				t.Errorf("%s: bad line for token %d: got %d, expected %d", tt.name, i, line, tt.lines[i])
+			}
+		}
+	}
+}
+
+func TestPosition(t *testing.T) {
+	src := "a = record {\n\tid: i32;\n}"
+	want := [...]token.Position{
+		{Offset: 0, Line: 1, Column: 1},   // a
+		{Offset: 2, Line: 1, Column: 3},   // =
+		{Offset: 4, Line: 1, Column: 5},   // record
+		{Offset: 11, Line: 1, Column: 12}, // {
+		{Offset: 14, Line: 2, Column: 2},  // id
+		{Offset: 16, Line: 2, Column: 4},  // :
+		{Offset: 18, Line: 2, Column: 6},  // i32
+		{Offset: 21, Line: 2, Column: 9},  // ;
+		{Offset: 23, Line: 3, Column: 1},  // }
+	}
+
+	var s scanner.Scanner
+	s.Init([]byte(src), nil, 0)
+
+	for i, pos := range want {
+		tok, _ := s.Scan()
+		if got := s.Position(); got != pos {
+			t.Errorf("bad position for token %d (%s): got %+v, expected %+v", i, tok, got, pos)
+		}
+	}
+}
+
+func TestScanTrivia(t *testing.T) {
+	src := "# doc\r\nmy_record = record +c {\n\tid: i32; # trailing\n\n\tnames: list<string>;\n}\n"
+
+	var s scanner.Scanner
+	s.Init([]byte(src), nil, scanner.ScanTrivia)
+
+	var b strings.Builder
+	for {
+		tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.ILLEGAL {
+			t.Fatalf("illegal token %q at %s", lit, s.Position())
+		}
+		if lit == "" {
+			lit = tok.String()
+		}
+		b.WriteString(lit)
+	}
+
+	if got := b.String(); got != src {
+		t.Errorf("incorrect reconstruction:\ngot:\n%q\nwant:\n%q", got, src)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	src := "a = record +x {\n\tid: i32; $\n}"
+
+	want := []scanner.TokenInfo{
+		{token.Position{Offset: 0, Line: 1, Column: 1}, token.IDENT, "a"},
+		{token.Position{Offset: 2, Line: 1, Column: 3}, token.ASSIGN, ""},
+		{token.Position{Offset: 4, Line: 1, Column: 5}, token.RECORD, "record"},
+		{token.Position{Offset: 11, Line: 1, Column: 12}, token.ILLEGAL, "+"},
+		{token.Position{Offset: 12, Line: 1, Column: 13}, token.IDENT, "x"},
+		{token.Position{Offset: 14, Line: 1, Column: 15}, token.LBRACE, ""},
+		{token.Position{Offset: 17, Line: 2, Column: 2}, token.IDENT, "id"},
+		{token.Position{Offset: 19, Line: 2, Column: 4}, token.COLON, ""},
+		{token.Position{Offset: 21, Line: 2, Column: 6}, token.IDENT, "i32"},
+		{token.Position{Offset: 24, Line: 2, Column: 9}, token.SEMICOLON, ""},
+		{token.Position{Offset: 26, Line: 2, Column: 11}, token.ILLEGAL, "$"},
+		{token.Position{Offset: 28, Line: 3, Column: 1}, token.RBRACE, ""},
+	}
+
+	got := scanner.Tokenize([]byte(src))
+	if len(got) != len(want) {
+		t.Fatalf("incorrect number of tokens; expected %d, got %d: %v", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("bad token %d: got %+v, expected %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// A '>' is always scanned on its own, so that nested generics such as
+// map<string, list<i32>> end in one RANGLE per type.
+func TestScanNestedAngles(t *testing.T) {
+	src := "map<string, list<i32>>>"
+
+	want := []scanner.TokenInfo{
+		{token.Position{Offset: 0, Line: 1, Column: 1}, token.MAP, "map"},
+		{token.Position{Offset: 3, Line: 1, Column: 4}, token.LANGLE, ""},
+		{token.Position{Offset: 4, Line: 1, Column: 5}, token.IDENT, "string"},
+		{token.Position{Offset: 10, Line: 1, Column: 11}, token.COMMA, ""},
+		{token.Position{Offset: 12, Line: 1, Column: 13}, token.LIST, "list"},
+		{token.Position{Offset: 16, Line: 1, Column: 17}, token.LANGLE, ""},
+		{token.Position{Offset: 17, Line: 1, Column: 18}, token.IDENT, "i32"},
+		{token.Position{Offset: 20, Line: 1, Column: 21}, token.RANGLE, ""},
+		{token.Position{Offset: 21, Line: 1, Column: 22}, token.RANGLE, ""},
+		{token.Position{Offset: 22, Line: 1, Column: 23}, token.RANGLE, ""},
+	}
+
+	got := scanner.Tokenize([]byte(src))
+	if len(got) != len(want) {
+		t.Fatalf("incorrect number of tokens; expected %d, got %d: %v", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("bad token %d: got %+v, expected %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestScanStrings(t *testing.T) {
+	tests := [...]string{
+		`""`,
+		`"plain"`,
+		`"line1\nline2"`,
+		`"he said \"hi\""`,
+		`"tab\tand backslash\\"`,
+	}
+
+	for _, src := range tests {
+		var s scanner.Scanner
+		s.Init([]byte(src+";"), nil, 0)
+
+		if tok, lit := s.Scan(); tok != token.STRING || lit != src {
+			t.Errorf("bad string for %s: got %s %s", src, tok, lit)
+		}
+		if tok, _ := s.Scan(); tok != token.SEMICOLON {
+			t.Errorf("%s: expected the string to end before ';', got %s", src, tok)
+		}
+	}
+}
+
+func TestScanNumbers(t *testing.T) {
+	tests := [...]el{
+		{token.INT, "0"},
+		{token.INT, "42"},
+		{token.INT, "0xFF"},
+		{token.INT, "0Xdead"},
+		{token.INT, "0b1010"},
+		{token.INT, "0B1"},
+		{token.INT, "0o755"},
+		{token.INT, "0x"},
+		{token.FLOAT, "0.5"},
+		{token.FLOAT, "12.25"},
+		{token.FLOAT, ".5"},
+		{token.FLOAT, "1.5e10"},
+		{token.FLOAT, "2E-3"},
+		{token.FLOAT, "1e+6"},
+		{token.FLOAT, ".25e2"},
+	}
+
+	for _, e := range tests {
+		var s scanner.Scanner
+		s.Init([]byte(e.lit+";"), nil, 0)
+
+		if tok, lit := s.Scan(); tok != e.tok || lit != e.lit {
+			t.Errorf("bad number for %s: got %s %s, expected %s", e.lit, tok, lit, e.tok)
+		}
+		if tok, _ := s.Scan(); tok != token.SEMICOLON {
+			t.Errorf("%s: expected the number to end before ';', got %s", e.lit, tok)
+		}
+	}
+}
+
+func TestIntValue(t *testing.T) {
+	tests := [...]struct {
+		lit  string
+		want int64
+	}{
+		{"0", 0},
+		{"42", 42},
+		{"010", 10},
+		{"09", 9},
+		{"0x1F", 31},
+		{"0B101", 5},
+		{"0o17", 15},
+		{"-010", -10},
+		{"-0x10", -16},
+	}
+	for _, tt := range tests {
+		if got, err := scanner.IntValue(tt.lit); err != nil || got != tt.want {
+			t.Errorf("%s: expected %d, got %d (%v)", tt.lit, tt.want, got, err)
+		}
+	}
+	for _, lit := range []string{"0x", "0o8", "0b2", "9223372036854775808"} {
+		if _, err := scanner.IntValue(lit); err == nil {
+			t.Errorf("%s: expected an error", lit)
+		}
+	}
+}
+
+func TestScanErrors(t *testing.T) {
+	tests := [...]struct {
+		src string
+		tok token.Token
+		pos token.Position
+		msg string
+	}{
+		{"$", token.ILLEGAL, token.Position{Offset: 0, Line: 1, Column: 1}, "illegal character U+0024 '$'"},
+		{"a\n  \u20ac", token.ILLEGAL, token.Position{Offset: 4, Line: 2, Column: 3}, "illegal character U+20AC '\u20ac'"},
+		{"@ json", token.ILLEGAL, token.Position{Offset: 0, Line: 1, Column: 1}, "expected annotation name after '@'"},
+		{"+x", token.ILLEGAL, token.Position{Offset: 0, Line: 1, Column: 1}, "expected language flag after '+'"},
+		{`x = "abc`, token.STRING, token.Position{Offset: 4, Line: 1, Column: 5}, "unterminated string literal"},
+		{"\"abc\ndef", token.STRING, token.Position{Offset: 0, Line: 1, Column: 1}, "unterminated string literal"},
+		{"0b2", token.INT, token.Position{Offset: 0, Line: 1, Column: 1}, "binary literal has no digits"},
+		{"\xff", token.ILLEGAL, token.Position{Offset: 0, Line: 1, Column: 1}, "illegal UTF-8 encoding"},
+		{"a\uFEFF", token.ILLEGAL, token.Position{Offset: 1, Line: 1, Column: 2}, "illegal byte order mark"},
+		{"\x00", token.ILLEGAL, token.Position{Offset: 0, Line: 1, Column: 1}, "illegal character NUL"},
+	}
+
+	for _, tt := range tests {
+		var pos token.Position
+		var msg string
+		n := 0
+		eh := func(p token.Position, m string) {
+			pos, msg = p, m
+			n++
+		}
+
+		var s scanner.Scanner
+		s.Init([]byte(tt.src), eh, 0)
+		found := false
+		for {
+			tok, _ := s.Scan()
+			if tok == tt.tok && n == 1 {
+				found = true
+			}
+			if tok == token.EOF {
+				break
+			}
+		}
+
+		if n != 1 || s.ErrorCount != 1 {
+			t.Errorf("%q: expected 1 error, got %d (ErrorCount %d)", tt.src, n, s.ErrorCount)
+			continue
+		}
+		if !found {
+			t.Errorf("%q: expected the error with a %s token", tt.src, tt.tok)
+		}
+		if pos != tt.pos || msg != tt.msg {
+			t.Errorf("%q: incorrect error: got %s: %s, expected %s: %s", tt.src, pos, msg, tt.pos, tt.msg)
+		}
+	}
+}
+
+func TestScanUTF8(t *testing.T) {
+	src := "\uFEFFname = \"caf\u00e9\" \u20ac \u0661a # \u65e5\u672c\nx \xff"
+	want := []el{
+		{token.IDENT, "name"},
+		{token.ASSIGN, ""},
+		{token.STRING, "\"caf\u00e9\""},
+		{token.ILLEGAL, "\u20ac"},
+		{token.ILLEGAL, "\u0661"}, // identifiers can't start with a digit
+		{token.IDENT, "a"},
+		{token.COMMENT, "# \u65e5\u672c"},
+		{token.IDENT, "x"},
+		{token.ILLEGAL, "\xff"}, // the offending byte, not utf8.RuneError
+		{token.EOF, ""},
+	}
+
+	var s scanner.Scanner
+	s.Init([]byte(src), nil, 0)
+
+	for i, e := range want {
+		if tok, lit := s.Scan(); tok != e.tok || lit != e.lit {
+			t.Errorf("bad token %d: got %s %q, expected %s %q", i, tok, lit, e.tok, e.lit)
+		}
+	}
+}
+
+// largeFile returns the source of n generated records and interfaces.
+func largeFile(n int) []byte {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "# record number %d\n", i)
+		fmt.Fprintf(&b, "record_%d = record +c +j {\n", i)
+		b.WriteString("\tid: i64; # the id\n\tname: string;\n\ttags: map<string, list<i32>>;\n")
+		fmt.Fprintf(&b, "\tconst max: f64 = %d.5e3;\n\tconst label: string = \"record \\\"%d\\\"\";\n", i, i)
+		b.WriteString("} deriving (eq, ord)\n\n")
+		fmt.Fprintf(&b, "interface_%d = interface +c +o {\n\tstatic create(id: i64): interface_%d;\n\tget(key: string): optional<record_%d>;\n}\n\n", i, i, i)
+	}
+	return []byte(b.String())
+}
+
+func BenchmarkScanLargeFile(b *testing.B) {
+	src := largeFile(1000)
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var s scanner.Scanner
+		s.Init(src, nil, 0)
+		for {
+			if tok, _ := s.Scan(); tok == token.EOF {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package token
+
+import "fmt"
+
+// Position describes a source position including the file, line, and
+// column location. A Position is valid if the line number is > 0.
+type Position struct {
+	Filename string // filename, if any
+	Offset   int    // offset, starting at 0
+	Line     int    // line number, starting at 1
+	Column   int    // column number, starting at 1 (byte count)
+}
+
+// IsValid reports whether the position is valid.
+func (pos Position) IsValid() bool { return pos.Line > 0 }
+
+// String returns a string in one of several forms:
+//
+//	file:line:column    valid position with file name
+//	line:column         valid position without file name
+//	file                invalid position with file name
+//	-                   invalid position without file name
+func (pos Position) String() string {
+	s := pos.Filename
+	if pos.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d:%d", pos.Line, pos.Column)
+	}
+	if s == "" {
+		s = "-"
+	}
+	return s
+}
+
+// Pos is the position of a node within its source file. Unlike a
+// Position, it does not repeat the filename for every node.
+// The zero value NoPos is not a valid position.
+type Pos struct {
+	Offset int // offset, starting at 0
+	Line   int // line number, starting at 1
+	Column int // column number, starting at 1 (byte count)
+}
+
+// NoPos is the zero value for Pos; there is no line information
+// associated with it.
+var NoPos = Pos{}
+
+// IsValid reports whether the position is valid.
+func (p Pos) IsValid() bool { return p.Line > 0 }
+
+// Position returns the Position of p within the named file.
+func (p Pos) Position(filename string) Position {
+	return Position{
+		Filename: filename,
+		Offset:   p.Offset,
+		Line:     p.Line,
+		Column:   p.Column,
+	}
+}
@@ -0,0 +1,31 @@
+package token
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestString checks that every token, except the unexported range
+// markers, has a readable name for error messages.
+func TestString(t *testing.T) {
+	t.Parallel()
+	markers := map[Token]bool{keyword_beg: true, keyword_end: true, ext_beg: true, ext_end: true}
+
+	for tok := ILLEGAL; tok < ext_end; tok++ {
+		if markers[tok] {
+			continue
+		}
+		s := tok.String()
+		if s == "" || strings.HasPrefix(s, "token(") {
+			t.Errorf("token %d has no name, got %q", int(tok), s)
+		}
+		if _, err := strconv.Atoi(s); err == nil {
+			t.Errorf("token %d has a numeric name %q", int(tok), s)
+		}
+	}
+
+	if s := ext_end.String(); s != "token("+strconv.Itoa(int(ext_end))+")" {
+		t.Errorf("expected an unknown token to print its number, got %q", s)
+	}
+}
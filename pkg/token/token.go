@@ -14,6 +14,7 @@ const (
 	ILLEGAL Token = iota
 	EOF
 	COMMENT
+	WHITESPACE // only returned in scanner.ScanTrivia mode
 
 	// Identifiers and basic type literals
 	IDENT  // my_record
@@ -21,18 +22,24 @@ const (
 	FLOAT  // 123.45
 	STRING // "abc"
 
+	ANNOTATION // @json
+
 	ASSIGN // =
+	MINUS  // -
 
 	LPAREN // (
 	RPAREN // )
 	LBRACE // {
 	RBRACE // }
+	LBRACK // [
+	RBRACK // ]
 	LANGLE // <
 	RANGLE // >
 
 	COMMA     // ,
 	SEMICOLON // ;
 	COLON     // :
+	PERIOD    // .
 
 	keyword_beg
 	// Type Keywords
@@ -44,6 +51,7 @@ const (
 	MAP
 	SET
 	LIST
+	OPTIONAL
 
 	DERIVING
 	EQUALITY
@@ -53,6 +61,7 @@ const (
 	STATIC
 	CONST
 	IMPORT
+	EXTERN
 	keyword_end
 
 	// Language extension flags
@@ -60,6 +69,8 @@ const (
 	CPP
 	OBJC
 	JAVA
+	WASM
+	NODE
 	ext_end
 )
 
@@ -68,32 +79,41 @@ var tokens = [...]string{
 	EOF:     "EOF",
 	COMMENT: "COMMENT",
 
+	WHITESPACE: "WHITESPACE",
+
 	IDENT:  "IDENT",
 	INT:    "INT",
 	FLOAT:  "FLOAT",
 	STRING: "STRING",
 
+	ANNOTATION: "ANNOTATION",
+
 	ASSIGN: "=",
+	MINUS:  "-",
 
 	LPAREN: "(",
 	RPAREN: ")",
 	LBRACE: "{",
 	RBRACE: "}",
+	LBRACK: "[",
+	RBRACK: "]",
 	LANGLE: "<",
 	RANGLE: ">",
 
 	COMMA:     ",",
 	SEMICOLON: ";",
 	COLON:     ":",
+	PERIOD:    ".",
 
 	ENUM:      "enum",
 	FLAGS:     "flags",
 	RECORD:    "record",
 	INTERFACE: "interface",
 
-	MAP:  "map",
-	SET:  "set",
-	LIST: "list",
+	MAP:      "map",
+	SET:      "set",
+	LIST:     "list",
+	OPTIONAL: "optional",
 
 	DERIVING:   "deriving",
 	EQUALITY:   "eq",
@@ -103,10 +123,13 @@ var tokens = [...]string{
 	STATIC: "static",
 	CONST:  "const",
 	IMPORT: "@import",
+	EXTERN: "@extern",
 
 	CPP:  "+c",
 	OBJC: "+o",
 	JAVA: "+j",
+	WASM: "+w",
+	NODE: "+n",
 }
 
 // String returns the string corresponding to the token tok.
@@ -136,6 +159,7 @@ func init() {
 }
 
 // Lookup maps an identifier to its keyword token or IDENT (if not a keyword).
+// Keywords are case sensitive, so Lookup("Record") is IDENT.
 func Lookup(ident string) Token {
 	if tok, is_keyword := keywords[ident]; is_keyword {
 		return tok
@@ -143,6 +167,24 @@ func Lookup(ident string) Token {
 	return IDENT
 }
 
+var primitives = map[string]bool{
+	"i8":     true,
+	"i16":    true,
+	"i32":    true,
+	"i64":    true,
+	"f32":    true,
+	"f64":    true,
+	"bool":   true,
+	"string": true,
+	"binary": true, // byte buffer
+	"date":   true,
+}
+
+// IsPrimitive reports whether name is a builtin Djinni type, such as i32,
+// string, binary or date. The generic map, set, list and optional types are
+// keywords rather than primitives.
+func IsPrimitive(name string) bool { return primitives[name] }
+
 // IsTypeDef returns true for tokens corresponding to type defs;
 // it returns false otherwise.
 func (tok Token) IsTypeDef() bool { return ENUM <= tok && tok <= INTERFACE }
@@ -0,0 +1,47 @@
+package token_test
+
+import (
+	"testing"
+
+	"github.com/SafetyCulture/djinni-parser/pkg/token"
+)
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		ident string
+		want  token.Token
+	}{
+		{"enum", token.ENUM},
+		{"flags", token.FLAGS},
+		{"record", token.RECORD},
+		{"interface", token.INTERFACE},
+		{"map", token.MAP},
+		{"set", token.SET},
+		{"list", token.LIST},
+		{"optional", token.OPTIONAL},
+		{"deriving", token.DERIVING},
+		{"eq", token.EQUALITY},
+		{"ord", token.ORDERING},
+		{"parcelable", token.PARCELABLE},
+		{"static", token.STATIC},
+		{"const", token.CONST},
+		{"@import", token.IMPORT},
+		{"@extern", token.EXTERN},
+
+		{"i32", token.IDENT},
+		{"string", token.IDENT},
+		{"Record", token.IDENT},
+		{"records", token.IDENT},
+		{"import", token.IDENT},
+		{"+c", token.IDENT},
+		{"", token.IDENT},
+	}
+
+	for _, tt := range tests {
+		if got := token.Lookup(tt.ident); got != tt.want {
+			t.Errorf("Lookup(%q): got %s, expected %s", tt.ident, got, tt.want)
+		}
+	}
+}